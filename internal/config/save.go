@@ -0,0 +1,202 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"agent-t/internal/fileutil"
+)
+
+// Warnings is where Save reports what it could not keep of the config
+// file while still saving it.
+var Warnings io.Writer = os.Stderr
+
+// Backups is how many earlier versions of the config file Save keeps, as
+// config.yaml.bak.1 (the most recent) to config.yaml.bak.N.
+const Backups = 5
+
+// Save writes cfg to the config file, in the current format.
+//
+// Several agent-t instances may save at once, so Save holds a lock on the
+// file while it reads it, merges in cfg's changes and replaces it. Changes
+// are what differs in cfg from the config it was loaded as: they win over
+// the file, and everything else in the file, such as presets saved by
+// another instance since, is kept. Only the user's config file is written;
+// settings from system-wide files are saved only when they were changed.
+// cfg is updated to what was written.
+//
+// Settings that did not change keep their text in the file, comments and
+// blank lines included. The file is replaced by renaming a complete
+// temporary file over it, so it is never left half written, and the
+// version it replaces is kept as the first of the rotating backups.
+func Save(cfg *Config) error {
+	if err := cfg.checkCommands(); err != nil {
+		return err
+	}
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	// A symlinked config file, e.g. kept in a dotfiles repository, stays
+	// a symlink: the file it points to is the one rewritten
+	target, err := fileutil.Resolve(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	unlock, err := fileutil.Lock(target + ".lock")
+	if err != nil {
+		return fmt.Errorf("locking %s: %w", path, err)
+	}
+	defer unlock()
+
+	old, err := os.ReadFile(target)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	disk, err := Parse(old)
+	if err != nil {
+		return fmt.Errorf("%s was changed and cannot be read, not overwriting it: %w", path, err)
+	}
+	if err := disk.checkVersion(); err != nil {
+		return fmt.Errorf("%s: %w, not overwriting it", path, err)
+	}
+	base := cfg.base
+	if base == nil {
+		base = &Config{}
+	}
+	merged := merge(base, cfg, disk)
+	merged.Version = Version
+
+	data, lost, err := encode(old, disk, merged)
+	if err != nil {
+		return err
+	}
+	if old != nil && string(data) != string(old) {
+		if err := backup(target, old); err != nil {
+			return fmt.Errorf("backing up %s: %w", path, err)
+		}
+	}
+	if err := fileutil.WriteAtomic(target, data); err != nil {
+		return err
+	}
+	if lost {
+		warnCommentsLost(path)
+	}
+	written, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	written.file = path
+	// Keep the system-wide layers, and read the user's includes again
+	var layers []*Config
+	for _, l := range cfg.layers {
+		if l.file != path && l.includedBy != path {
+			layers = append(layers, l)
+		}
+	}
+	*cfg = *layered(append(layers, withIncludes(written)...))
+	return nil
+}
+
+// merge applies the changes from base to mine on top of disk.
+func merge(base, mine, disk *Config) *Config {
+	merged := *disk
+	mergeField(&merged.DefaultLayout, base.DefaultLayout, mine.DefaultLayout)
+	mergeField(&merged.DefaultTool, base.DefaultTool, mine.DefaultTool)
+	mergeField(&merged.Backend, base.Backend, mine.Backend)
+	mergeField(&merged.WorktreeDir, base.WorktreeDir, mine.WorktreeDir)
+	mergeField(&merged.ScanDepth, base.ScanDepth, mine.ScanDepth)
+	mergeField(&merged.ProjectRoots, base.ProjectRoots, mine.ProjectRoots)
+	mergeField(&merged.ScanCwd, base.ScanCwd, mine.ScanCwd)
+
+	merged.CustomCommands = maps.Clone(disk.CustomCommands)
+	for name, cmd := range mine.CustomCommands {
+		if old, ok := base.CustomCommands[name]; !ok || old != cmd {
+			merged.CustomCommands[name] = cmd
+		}
+	}
+	for name := range base.CustomCommands {
+		if _, ok := mine.CustomCommands[name]; !ok {
+			delete(merged.CustomCommands, name)
+		}
+	}
+
+	merged.Presets = mergeByName(base.Presets, mine.Presets, disk.Presets,
+		func(p Preset) string { return p.Name })
+	merged.CustomLayouts = mergeByName(base.CustomLayouts, mine.CustomLayouts, disk.CustomLayouts,
+		func(l CustomLayout) string { return l.Name })
+	return &merged
+}
+
+// mergeField sets *dst to mine when it differs from base.
+func mergeField[T any](dst *T, base, mine T) {
+	if !reflect.DeepEqual(base, mine) {
+		*dst = mine
+	}
+}
+
+// mergeByName merges lists of named items: items added or changed in mine
+// replace those of the same name in disk, or are appended; items removed
+// in mine are removed from disk. The order of disk is kept.
+func mergeByName[T any](base, mine, disk []T, name func(T) string) []T {
+	index := func(items []T) map[string]T {
+		m := make(map[string]T, len(items))
+		for _, it := range items {
+			if _, ok := m[name(it)]; !ok {
+				m[name(it)] = it
+			}
+		}
+		return m
+	}
+	inBase, inMine, onDisk := index(base), index(mine), index(disk)
+	changed := func(n string) bool {
+		b, ok := inBase[n]
+		return !ok || !reflect.DeepEqual(b, inMine[n])
+	}
+
+	var merged []T
+	for _, d := range disk {
+		n := name(d)
+		_, kept := inMine[n]
+		_, wasThere := inBase[n]
+		switch {
+		case wasThere && !kept:
+			// removed here
+		case kept && changed(n):
+			merged = append(merged, inMine[n])
+		default:
+			merged = append(merged, d)
+		}
+	}
+	for _, m := range mine {
+		if _, ok := onDisk[name(m)]; !ok && changed(name(m)) {
+			merged = append(merged, m)
+		}
+	}
+	return merged
+}
+
+// warnCommentsLost reports that the config file at path was written out
+// whole, which dropped its comments.
+func warnCommentsLost(path string) {
+	fmt.Fprintf(Warnings, "Warning: %s could not be edited in place; it was rewritten without its comments, the previous version is in %s.bak.1\n", path, path)
+}
+
+// backup rotates the backups of path and keeps data as the most recent.
+func backup(path string, data []byte) error {
+	for i := Backups - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.bak.%d", path, i), fmt.Sprintf("%s.bak.%d", path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return fileutil.WriteAtomic(path+".bak.1", data)
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("AGENT_T_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "etc"))
+	path, err := Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func presetNames(cfg *Config) string {
+	var names []string
+	for _, p := range cfg.Presets {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSave_MergesConcurrentChanges(t *testing.T) {
+	path := writeConfig(t, `default_tool: Codex # team default
+presets:
+  - {name: a, project: api, layout: "2"}
+  - {name: b, project: api, layout: "2"}
+`)
+	first, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first.AddPreset(Preset{Name: "x", Project: "web", Layout: "2,2"})
+	first.DefaultLayout = "2,2"
+	if err := Save(first); err != nil {
+		t.Fatalf("first Save: %v", err)
+	}
+	second.DeletePreset("b")
+	second.AddPreset(Preset{Name: "y", Project: "web", Layout: "3"})
+	second.CustomCommands["Dev"] = "make dev"
+	if err := Save(second); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := presetNames(cfg); got != "a,x,y" {
+		t.Errorf("presets = %s, want a,x,y", got)
+	}
+	if cfg.DefaultLayout != "2,2" || cfg.DefaultTool != "Codex" || cfg.CustomCommands["Dev"] != "make dev" {
+		t.Errorf("settings were not merged: %+v", cfg)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "# team default") {
+		t.Errorf("Save dropped a comment:\n%s", data)
+	}
+	if got := presetNames(second); got != "a,x,y" {
+		t.Errorf("Save should update the saved config, presets = %s", got)
+	}
+
+	bak1, _ := os.ReadFile(path + ".bak.1")
+	bak2, _ := os.ReadFile(path + ".bak.2")
+	if !strings.Contains(string(bak1), "name: x") || strings.Contains(string(bak2), "name: x") || !strings.Contains(string(bak2), "name: b") {
+		t.Errorf("backups should hold the previous versions, newest first:\n%s\n---\n%s", bak1, bak2)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp") {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestSave_RotatesBackups(t *testing.T) {
+	path := writeConfig(t, "default_tool: v0\n")
+	for i := 1; i <= Backups+2; i++ {
+		cfg, err := Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.DefaultTool = fmt.Sprintf("v%d", i)
+		if err := Save(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i <= Backups; i++ {
+		data, err := os.ReadFile(fmt.Sprintf("%s.bak.%d", path, i))
+		if want := fmt.Sprintf("v%d", Backups+2-i); err != nil || !strings.Contains(string(data), want) {
+			t.Errorf("bak.%d = %q, %v; want %s", i, data, err, want)
+		}
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s.bak.%d", path, Backups+1)); !os.IsNotExist(err) {
+		t.Errorf("only %d backups should be kept", Backups)
+	}
+}
+
+func TestSave_SymlinkedFile(t *testing.T) {
+	path := writeConfig(t, "default_layout: \"2x2\"\n")
+	real := filepath.Join(t.TempDir(), "dotfiles", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(real), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, real); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(real, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(real, path); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	// Load upgrades the version 0 file, then Save changes it
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg.DefaultTool = "Codex"
+	if err := Save(cfg); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if fi, err := os.Lstat(path); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("config path should still be a symlink: %v, %v", fi, err)
+	}
+	data, _ := os.ReadFile(real)
+	if !strings.Contains(string(data), "default_tool: Codex") || !strings.Contains(string(data), `"2,2"`) {
+		t.Errorf("linked file =\n%s\nwant it upgraded and saved", data)
+	}
+	if fi, err := os.Stat(real); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("linked file mode = %v, %v; want 0600 kept", fi.Mode().Perm(), err)
+	}
+	if _, err := os.Stat(real + ".bak.2"); err != nil {
+		t.Errorf("backups should be kept next to the linked file: %v", err)
+	}
+}
+
+func TestSave_Parallel(t *testing.T) {
+	writeConfig(t, "")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cfg, err := Load()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			cfg.AddPreset(Preset{Name: fmt.Sprintf("p%d", i), Project: "api", Layout: "2"})
+			if err := Save(cfg); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Presets) != 8 {
+		t.Errorf("presets = %s, want all 8", presetNames(cfg))
+	}
+}
@@ -0,0 +1,317 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"agent-t/internal/launcher"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MaxCells is the most terminals a layout may have.
+const MaxCells = 20
+
+// Problem is something wrong in the config file that agent-t works around
+// by ignoring it.
+type Problem struct {
+	File    string // empty for a config not read from a file
+	Line    int    // 0 when the line is not known
+	Message string
+}
+
+func (p Problem) String() string {
+	switch {
+	case p.File != "" && p.Line != 0:
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	case p.File != "":
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	case p.Line != 0:
+		return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+	}
+	return p.Message
+}
+
+// Names are what a config may refer to beyond its own custom commands and
+// layouts: the projects found, the built-in tools and layout IDs, and the
+// commands of repository config files.
+type Names struct {
+	Projects []string // nil skips checking preset projects
+	Tools    []string
+	Layouts  []string
+}
+
+// Parse decodes a config file, upgrading files of an earlier version to
+// the current format; Version is left as the file has it. Keys agent-t
+// does not know are not an error; they are kept as problems for Validate
+// to report, along with where they are.
+func Parse(data []byte) (*Config, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var cfg, base Config
+	if len(doc.Content) > 0 {
+		cfg.node = doc.Content[0]
+		current, err := migrate(cfg.node)
+		if err != nil {
+			return nil, err
+		}
+		if err := current.Decode(&cfg); err != nil {
+			return nil, err
+		}
+		if err := current.Decode(&base); err != nil {
+			return nil, err
+		}
+		cfg.problems = unknownKeys(current, reflect.TypeOf(cfg), "")
+		cfg.problems = append(cfg.problems, cfg.dropInvalidEnv()...)
+		base.dropInvalidEnv()
+		if err := cfg.checkVersion(); err != nil {
+			cfg.problems = append(cfg.problems, Problem{Line: cfg.line("version"), Message: err.Error() + "; changes will not be saved"})
+		}
+	}
+	cfg.base = &base
+	if cfg.CustomCommands == nil {
+		cfg.CustomCommands = make(map[string]string)
+	}
+	return &cfg, nil
+}
+
+// dropInvalidEnv removes the env entries of preset cells whose names are
+// not valid variable names, which would otherwise end up in a shell
+// command line, and reports them.
+func (c *Config) dropInvalidEnv() []Problem {
+	var problems []Problem
+	for i, p := range c.Presets {
+		for j, cell := range p.Cells {
+			for _, name := range sortedKeys(cell.Env) {
+				if !launcher.ValidEnvName(name) {
+					problems = append(problems, Problem{
+						Line:    c.line("presets", i, "cells", j, "env"),
+						Message: fmt.Sprintf("preset %q: cell %d: env name %q is not a valid variable name", p.Name, j+1, name),
+					})
+					delete(cell.Env, name)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// unknownKeys reports the mapping keys under n that have no field in t.
+func unknownKeys(n *yaml.Node, t reflect.Type, path string) []Problem {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var problems []Problem
+	switch {
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if f.IsExported() && name != "-" && name != "" {
+				fields[name] = f.Type
+			}
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, val := n.Content[i], n.Content[i+1]
+			ft, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, Problem{Line: key.Line, Message: fmt.Sprintf("unknown key %q", path+key.Value)})
+				continue
+			}
+			problems = append(problems, unknownKeys(val, ft, path+key.Value+".")...)
+		}
+	case t.Kind() == reflect.Map && n.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			problems = append(problems, unknownKeys(n.Content[i+1], t.Elem(), path+n.Content[i].Value+".")...)
+		}
+	case t.Kind() == reflect.Slice && n.Kind == yaml.SequenceNode:
+		prefix := strings.TrimSuffix(path, ".")
+		for i, item := range n.Content {
+			problems = append(problems, unknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d].", prefix, i))...)
+		}
+	}
+	return problems
+}
+
+// Validate checks c for unknown keys and for settings that refer to
+// nothing, such as a preset whose project was not found. A loaded config is
+// checked file by file, so each problem has its file and line. It returns
+// every problem found, ordered by file and line.
+func (c *Config) Validate(names Names) []Problem {
+	layouts := make(map[string]bool)
+	for _, id := range names.Layouts {
+		layouts[id] = true
+	}
+	for _, cl := range c.CustomLayouts {
+		if CheckRowCols(cl.RowCols) == nil {
+			layouts[rowColsID(cl.RowCols)] = true
+		}
+	}
+	tools := map[string]bool{"": true, "None": true}
+	for _, name := range names.Tools {
+		tools[name] = true
+	}
+	for name := range c.CustomCommands {
+		tools[name] = true
+	}
+	projects := make(map[string]bool)
+	for _, name := range names.Projects {
+		projects[name] = true
+	}
+
+	layers := c.layers
+	if len(layers) == 0 {
+		layers = []*Config{c}
+	}
+	var problems []Problem
+	for _, l := range layers {
+		found := l.check(names.Projects != nil, projects, tools, layouts)
+		sort.SliceStable(found, func(i, j int) bool {
+			return found[i].Line < found[j].Line
+		})
+		for _, p := range found {
+			p.File = l.file
+			problems = append(problems, p)
+		}
+	}
+	return problems
+}
+
+// check validates one config file against the projects, tools and layouts
+// known.
+func (c *Config) check(checkProjects bool, projects, tools, layouts map[string]bool) []Problem {
+	problems := append([]Problem(nil), c.problems...)
+	add := func(line int, format string, args ...any) {
+		problems = append(problems, Problem{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for i, cl := range c.CustomLayouts {
+		if err := CheckRowCols(cl.RowCols); err != nil {
+			add(c.line("custom_layouts", i), "custom layout %q: %v", cl.Name, err)
+		}
+	}
+	if c.DefaultLayout != "" && !layouts[c.DefaultLayout] {
+		add(c.line("default_layout"), "default_layout %q matches no layout", c.DefaultLayout)
+	}
+	if c.DefaultTool != "" && !tools[c.DefaultTool] {
+		add(c.line("default_tool"), "default_tool %q matches no tool", c.DefaultTool)
+	}
+
+	seen := make(map[string]int)
+	for i, p := range c.Presets {
+		line := c.line("presets", i)
+		if first, ok := seen[p.Name]; ok {
+			add(line, "duplicate preset name %q, first defined on line %d", p.Name, first)
+		} else {
+			seen[p.Name] = line
+		}
+		if !layouts[p.Layout] {
+			add(c.line("presets", i, "layout"), "preset %q: layout %q not found", p.Name, p.Layout)
+		}
+		checkProject := func(name string, path ...any) {
+			if !checkProjects || name == "" || projects[name] {
+				return
+			}
+			if filepath.IsAbs(name) {
+				if fi, err := os.Stat(name); err == nil && fi.IsDir() {
+					return
+				}
+			}
+			add(c.line(append([]any{"presets", i}, path...)...), "preset %q: project %q not found", p.Name, name)
+		}
+		checkTool := func(name string, path ...any) {
+			if !tools[name] {
+				add(c.line(append([]any{"presets", i}, path...)...), "preset %q: tool %q not found", p.Name, name)
+			}
+		}
+		if len(p.Rows) == 0 {
+			if p.Project == "" {
+				add(line, "preset %q has no project", p.Name)
+			}
+			checkProject(p.Project, "project")
+			checkTool(p.Tool, "tool")
+		}
+		for j, r := range p.Rows {
+			checkProject(r.Project, "rows", j, "project")
+			checkTool(r.Tool, "rows", j, "tool")
+		}
+		for j, cell := range p.Cells {
+			checkProject(cell.Project, "cells", j, "project")
+			checkTool(cell.Tool, "cells", j, "tool")
+		}
+	}
+
+	return problems
+}
+
+// CheckRowCols reports why rowCols, the columns per row of a layout, is
+// not a layout agent-t can open.
+func CheckRowCols(rowCols []int) error {
+	if len(rowCols) == 0 {
+		return fmt.Errorf("row_cols is empty")
+	}
+	total := 0
+	for _, n := range rowCols {
+		if n < 1 {
+			return fmt.Errorf("row_cols has %d columns in a row, need at least 1", n)
+		}
+		total += n
+	}
+	if total > MaxCells {
+		return fmt.Errorf("row_cols has %d cells, at most %d are supported", total, MaxCells)
+	}
+	return nil
+}
+
+func rowColsID(rowCols []int) string {
+	parts := make([]string, len(rowCols))
+	for i, n := range rowCols {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ",")
+}
+
+// line returns the line of the value at path in the file c was parsed
+// from, following mapping keys (strings) and sequence indexes (ints). It
+// falls back to the closest parent found, and to 0 for configs not read
+// from a file.
+func (c *Config) line(path ...any) int {
+	n := c.node
+	if n == nil {
+		return 0
+	}
+	line := n.Line
+	for _, step := range path {
+		var next *yaml.Node
+		switch s := step.(type) {
+		case string:
+			if n.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(n.Content); i += 2 {
+					if n.Content[i].Value == s {
+						next = n.Content[i+1]
+						line = n.Content[i].Line
+						break
+					}
+				}
+			}
+		case int:
+			if n.Kind == yaml.SequenceNode && s < len(n.Content) {
+				next = n.Content[s]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			break
+		}
+		n = next
+	}
+	return line
+}
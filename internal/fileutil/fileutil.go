@@ -0,0 +1,90 @@
+// Package fileutil writes agent-t's config and state files safely when
+// several agent-t instances use them at once.
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Update replaces the file at path with what update makes of its
+// contents, nil when there is none. It holds a lock on the file meanwhile,
+// so concurrent updates, from this process or another agent-t, each start
+// from the result of the one before, and replaces the file atomically, so
+// it is never left half written. A new file is created 0644.
+func Update(path string, update func(old []byte) ([]byte, error)) error {
+	return UpdatePerm(path, 0o644, update)
+}
+
+// UpdatePerm is Update creating a new file with permissions perm.
+func UpdatePerm(path string, perm os.FileMode, update func(old []byte) ([]byte, error)) error {
+	path, err := Resolve(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	unlock, err := Lock(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("locking %s: %w", path, err)
+	}
+	defer unlock()
+
+	old, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	data, err := update(old)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(path, data, perm)
+}
+
+// Resolve follows the symlinks in path, so that writing the file replaces
+// what it points to rather than the link. A path that does not exist yet
+// is returned as is.
+func Resolve(path string) (string, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return path, nil
+	}
+	return target, err
+}
+
+// WriteAtomic replaces path with data through a temporary file in the same
+// directory, so readers see either the old or the new contents. The file
+// keeps its permissions; a new one is created 0644.
+func WriteAtomic(path string, data []byte) error {
+	return writeAtomic(path, data, 0o644)
+}
+
+func writeAtomic(path string, data []byte, mode os.FileMode) error {
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
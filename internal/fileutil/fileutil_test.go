@@ -0,0 +1,79 @@
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUpdate_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "lines")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := Update(path, func(old []byte) ([]byte, error) {
+				return append(old, fmt.Sprintf("%d\n", i)...), nil
+			})
+			if err != nil {
+				t.Errorf("Update: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 10 {
+		t.Errorf("concurrent Updates kept %d lines, want 10:\n%s", n, data)
+	}
+}
+
+func TestUpdate_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "real")
+	link := filepath.Join(dir, "link")
+	if err := os.WriteFile(real, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(real, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	err := Update(link, func(old []byte) ([]byte, error) {
+		if string(old) != "old" {
+			t.Errorf("old = %q, want the linked file's contents", old)
+		}
+		return []byte("new"), nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link should stay a symlink: %v, %v", fi, err)
+	}
+	if data, _ := os.ReadFile(real); string(data) != "new" {
+		t.Errorf("linked file = %q, want new", data)
+	}
+	if fi, err := os.Stat(real); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("linked file mode = %v, %v; want 0600 kept", fi.Mode().Perm(), err)
+	}
+}
+
+func TestUpdate_Error(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(path, []byte("keep"), 0o644)
+	err := Update(path, func([]byte) ([]byte, error) { return nil, fmt.Errorf("bad") })
+	if err == nil || err.Error() != "bad" {
+		t.Errorf("Update error = %v, want update's error", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep" {
+		t.Errorf("file = %q, want it left alone when update fails", data)
+	}
+}
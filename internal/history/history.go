@@ -0,0 +1,146 @@
+// Package history keeps a log of launched workspaces, used to rank the
+// projects opened most often and most recently first and to launch the
+// last workspace again.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"agent-t/internal/config"
+	"agent-t/internal/fileutil"
+)
+
+// maxEntries bounds the history; older launches are dropped.
+const maxEntries = 1000
+
+// Entry is one launched workspace.
+type Entry struct {
+	Time      time.Time           `json:"time"`
+	Projects  []string            `json:"projects"` // project paths, one per row of a split workspace
+	Layout    string              `json:"layout"`
+	Tools     []string            `json:"tools,omitempty"` // tool names, parallel to Projects
+	Split     bool                `json:"split,omitempty"`
+	Worktrees bool                `json:"worktrees,omitempty"`
+	Cells     []config.PresetCell `json:"cells,omitempty"` // with project paths
+}
+
+// Preset turns e back into an unnamed preset that launches the same
+// workspace. Projects are given by path.
+func (e Entry) Preset() config.Preset {
+	p := config.Preset{
+		Layout:    e.Layout,
+		Worktrees: e.Worktrees,
+		Cells:     e.Cells,
+	}
+	tool := func(i int) string {
+		if i < len(e.Tools) {
+			return e.Tools[i]
+		}
+		return ""
+	}
+	// Entries written before split was recorded only have several
+	// projects when they were split
+	if e.Split || len(e.Projects) > 1 {
+		for i, project := range e.Projects {
+			p.Rows = append(p.Rows, config.PresetRow{Project: project, Tool: tool(i)})
+		}
+	} else if len(e.Projects) > 0 {
+		p.Project = e.Projects[0]
+		p.Tool = tool(0)
+	}
+	return p
+}
+
+// Last returns the most recent entry in the history kept at path, or false
+// when nothing has been launched yet.
+func Last(path string) (Entry, bool, error) {
+	entries, err := Load(path)
+	if err != nil || len(entries) == 0 {
+		return Entry{}, false, err
+	}
+	return entries[len(entries)-1], true, nil
+}
+
+// Load reads the history kept at path, oldest first. A missing file means
+// an empty history.
+func Load(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parse(path, data)
+}
+
+func parse(path string, data []byte) ([]Entry, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Append adds e to the history kept at path. The file is locked while it
+// is updated, so concurrent launches do not drop each other's entries.
+func Append(path string, e Entry) error {
+	return fileutil.Update(path, func(old []byte) ([]byte, error) {
+		entries, err := parse(path, old)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+		if len(entries) > maxEntries {
+			entries = entries[len(entries)-maxEntries:]
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	})
+}
+
+// Frecency scores each project path in entries by how often and how
+// recently it was launched. Every launch counts for a weight that drops
+// as the launch ages, so a project used daily this week outranks one used
+// more often a few months ago.
+func Frecency(entries []Entry, now time.Time) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, e := range entries {
+		w := weight(now.Sub(e.Time))
+		seen := make(map[string]bool)
+		for _, p := range e.Projects {
+			// A project in several rows of one launch counts once
+			if !seen[p] {
+				seen[p] = true
+				scores[p] += w
+			}
+		}
+	}
+	return scores
+}
+
+func weight(age time.Duration) float64 {
+	const day = 24 * time.Hour
+	switch {
+	case age < 4*day:
+		return 100
+	case age < 14*day:
+		return 70
+	case age < 31*day:
+		return 50
+	case age < 90*day:
+		return 30
+	default:
+		return 10
+	}
+}
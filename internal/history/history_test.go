@@ -0,0 +1,121 @@
+package history
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"agent-t/internal/config"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "history.json")
+
+	entries, err := Load(path)
+	if err != nil || entries != nil {
+		t.Fatalf("Load of a missing file = %v, %v", entries, err)
+	}
+
+	first := Entry{Time: time.Unix(100, 0).UTC(), Projects: []string{"/p/api"}, Layout: "3,3", Tools: []string{"Claude Code"}}
+	second := Entry{Time: time.Unix(200, 0).UTC(), Projects: []string{"/p/api", "/p/web"}, Layout: "2,2", Tools: []string{"None", "Codex"}}
+	for _, e := range []Entry{first, second} {
+		if err := Append(path, e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	entries, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Layout != "3,3" || entries[1].Projects[1] != "/p/web" || !entries[1].Time.Equal(second.Time) {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+func TestAppend_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := Append(path, Entry{Time: time.Unix(int64(i), 0).UTC(), Layout: "2"}); err != nil {
+				t.Errorf("Append: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	entries, err := Load(path)
+	if err != nil || len(entries) != 10 {
+		t.Errorf("concurrent Appends kept %d entries, want 10 (%v)", len(entries), err)
+	}
+}
+
+func TestFrecency(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(daysAgo int, projects ...string) Entry {
+		return Entry{Time: now.AddDate(0, 0, -daysAgo), Projects: projects}
+	}
+	scores := Frecency([]Entry{
+		at(200, "/old"), at(200, "/old"), at(200, "/old"), at(200, "/old"),
+		at(1, "/daily"), at(2, "/daily"),
+		at(20, "/split", "/split"),
+	}, now)
+
+	if scores["/daily"] <= scores["/old"] {
+		t.Errorf("recent launches should outweigh old ones: %v", scores)
+	}
+	if scores["/split"] != 50 {
+		t.Errorf("a project in several rows of one launch should count once, got %v", scores["/split"])
+	}
+	if _, ok := scores["/never"]; ok {
+		t.Error("projects never launched should have no score")
+	}
+}
+
+func TestEntryPreset(t *testing.T) {
+	single := Entry{Projects: []string{"/p/api"}, Layout: "3,3", Tools: []string{"Claude Code"}, Worktrees: true}
+	p := single.Preset()
+	if p.Project != "/p/api" || p.Tool != "Claude Code" || p.Layout != "3,3" || !p.Worktrees || p.Rows != nil {
+		t.Errorf("single Preset() = %+v", p)
+	}
+
+	split := Entry{
+		Projects: []string{"/p/api", "/p/web"},
+		Layout:   "2,2",
+		Tools:    []string{"None", "Codex"},
+		Split:    true,
+		Cells:    []config.PresetCell{{}, {Title: "logs"}},
+	}
+	p = split.Preset()
+	want := []config.PresetRow{{Project: "/p/api", Tool: "None"}, {Project: "/p/web", Tool: "Codex"}}
+	if p.Project != "" || len(p.Rows) != 2 || p.Rows[0] != want[0] || p.Rows[1] != want[1] {
+		t.Errorf("split Preset() rows = %+v, want %+v", p.Rows, want)
+	}
+	if len(p.Cells) != 2 || p.Cells[1].Title != "logs" {
+		t.Errorf("split Preset() cells = %+v", p.Cells)
+	}
+
+	// Written before entries recorded split
+	split.Split = false
+	if p = split.Preset(); len(p.Rows) != 2 || p.Rows[1] != want[1] {
+		t.Errorf("Preset() of an older entry with two projects = %+v, want rows", p)
+	}
+}
+
+func TestLast(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	if _, ok, err := Last(path); ok || err != nil {
+		t.Errorf("Last of an empty history = %v, %v", ok, err)
+	}
+	for _, layout := range []string{"2", "3,3"} {
+		if err := Append(path, Entry{Projects: []string{"/p/api"}, Layout: layout}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if e, ok, err := Last(path); !ok || err != nil || e.Layout != "3,3" {
+		t.Errorf("Last = %+v, %v, %v", e, ok, err)
+	}
+}
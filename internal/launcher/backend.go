@@ -0,0 +1,81 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// Backend drives one terminal emulator or multiplexer. Launch resolves the
+// grid into a Plan once; a backend detects the area to tile, then opens each
+// cell, places it at its position in the grid and runs the cell's command.
+type Backend interface {
+	// Name is the identifier used by the `backend:` config key and --backend flag.
+	Name() string
+	// DetectBounds returns the usable area the grid should cover.
+	DetectBounds() (Rect, error)
+	// Script renders what Run would execute for the plan, without side effects.
+	Script(p Plan) (string, error)
+	// Run opens, places and starts every cell in the plan.
+	Run(p Plan) error
+}
+
+// DefaultBackend is used when neither the config nor the command line picks
+// one: Terminal.app on macOS, tmux everywhere else.
+var DefaultBackend = defaultBackend()
+
+var backends = map[string]func() Backend{
+	"iterm":    func() Backend { return itermBackend{} },
+	"kitty":    func() Backend { return kittyBackend{} },
+	"terminal": func() Backend { return terminalBackend{} },
+	"tmux":     func() Backend { return tmuxBackend{} },
+	"wezterm":  func() Backend { return weztermBackend{} },
+	"zellij":   func() Backend { return zellijBackend{} },
+}
+
+func defaultBackend() string {
+	if runtime.GOOS == "darwin" {
+		return "terminal"
+	}
+	return "tmux"
+}
+
+// NewBackend returns the backend registered under name. An empty name selects
+// DefaultBackend.
+func NewBackend(name string) (Backend, error) {
+	if name == "" {
+		name = DefaultBackend
+	}
+	newFn, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q (available: %s)", name, strings.Join(BackendNames(), ", "))
+	}
+	return newFn(), nil
+}
+
+// BackendNames lists the registered backends in alphabetical order.
+func BackendNames() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// terminalSize reports the size of the controlling terminal in character
+// cells, for backends that tile inside the terminal rather than the screen.
+func terminalSize() (Rect, error) {
+	w, h, err := term.GetSize(os.Stdout.Fd())
+	if err != nil {
+		return Rect{}, err
+	}
+	if w <= 0 || h <= 0 {
+		return Rect{}, fmt.Errorf("invalid terminal size %dx%d", w, h)
+	}
+	return Rect{X1: 0, Y1: 0, X2: w, Y2: h}, nil
+}
@@ -0,0 +1,86 @@
+package launcher
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// itermBackend opens a single iTerm2 window covering the screen and splits
+// it into one session per cell, instead of one window per cell.
+type itermBackend struct{}
+
+type itermScriptData struct {
+	X1, Y1, X2, Y2 int
+	Splits         []split
+	Cmds           []string // AppleScript string literals, one per cell
+	Titles         []string // AppleScript string literals, one per cell ("" = keep the profile's name)
+}
+
+func (itermBackend) Name() string { return "iterm" }
+
+func (itermBackend) DetectBounds() (Rect, error) {
+	bounds, err := detectScreen("iTerm2")
+	if err != nil {
+		bounds = fallbackBounds
+	}
+	bounds.Y1 += menuBarHeight
+	return bounds, nil
+}
+
+func (itermBackend) Script(p Plan) (string, error) {
+	script, err := buildItermScript(p)
+	if err != nil {
+		return "", fmt.Errorf("building AppleScript: %w", err)
+	}
+	return script, nil
+}
+
+func (i itermBackend) Run(p Plan) error {
+	script, err := i.Script(p)
+	if err != nil {
+		return err
+	}
+	return execAppleScript(script)
+}
+
+// buildItermScript renders the split sequence from Plan.splits as iTerm2
+// AppleScript: "split horizontally" adds a session below, "split vertically"
+// one to the right. iTerm2 always halves the session it splits, so the new
+// session is then resized to the split's share of the two, which makes the
+// rows, and the columns within a row, come out the same size. Each session
+// then gets its cell's shell line.
+func buildItermScript(p Plan) (string, error) {
+	if len(p.Cells) == 0 {
+		return "", fmt.Errorf("iterm: empty layout")
+	}
+
+	tmpl, err := template.New("iterm").Parse(itermScriptTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	cmds := make([]string, len(p.Cells))
+	titles := make([]string, len(p.Cells))
+	for i, c := range p.Cells {
+		cmds[i] = appleScriptString(c.ShellLine())
+		if c.Title != "" {
+			titles[i] = appleScriptString(c.Title)
+		}
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, itermScriptData{
+		X1:     p.Bounds.X1,
+		Y1:     p.Bounds.Y1,
+		X2:     p.Bounds.X2,
+		Y2:     p.Bounds.Y2,
+		Splits: p.splits(),
+		Cmds:   cmds,
+		Titles: titles,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,104 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildItermScript_SingleWindow(t *testing.T) {
+	plan := NewPlan(fakeBackend{bounds: Rect{X1: 0, Y1: 25, X2: 1920, Y2: 1080}}, Options{
+		ProjectDirs: []string{"/projects/api"},
+		RowCols:     []int{3, 3},
+		Commands:    []string{"claude"},
+	})
+
+	script, err := buildItermScript(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := strings.Count(script, "create window"); n != 1 {
+		t.Errorf("got %d windows, want exactly 1", n)
+	}
+	if !strings.Contains(script, "set bounds of newWindow to { 0, 25, 1920, 1080 }") {
+		t.Errorf("script should size the window to the screen:\n%s", script)
+	}
+	if n := strings.Count(script, "split horizontally"); n != 1 {
+		t.Errorf("got %d row splits, want 1", n)
+	}
+	if n := strings.Count(script, "split vertically"); n != 4 {
+		t.Errorf("got %d column splits, want 4", n)
+	}
+	if !strings.Contains(script, "tell s0\n        set s3 to (split horizontally with default profile)") {
+		t.Errorf("bottom row should be split off the first session:\n%s", script)
+	}
+	if n := strings.Count(script, "to write text"); n != 6 {
+		t.Errorf("got %d write text commands, want one per cell (6)", n)
+	}
+	if strings.Contains(script, "delay") {
+		t.Error("iTerm2 script should not need per-cell delays")
+	}
+}
+
+func TestBuildItermScript_SplitProjects(t *testing.T) {
+	plan := NewPlan(fakeBackend{}, Options{
+		ProjectDirs: []string{"/projects/api", "/projects/frontend"},
+		RowCols:     []int{2, 2},
+		Commands:    []string{"claude", "codex"},
+	})
+
+	script, err := buildItermScript(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(script, `tell s2 to write text "cd '/projects/frontend' && clear && codex"`) {
+		t.Errorf("bottom-left session should run codex in frontend:\n%s", script)
+	}
+	if !strings.Contains(script, `tell s1 to write text "cd '/projects/api' && clear && claude"`) {
+		t.Errorf("top-right session should run claude in api:\n%s", script)
+	}
+}
+
+func TestBuildItermScript_EscapesQuotes(t *testing.T) {
+	plan := NewPlan(fakeBackend{}, Options{
+		ProjectDirs: []string{`/projects/my "project"`},
+		RowCols:     []int{2},
+	})
+
+	script, err := buildItermScript(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(script, `\"project\"`) {
+		t.Error("script should escape double quotes in commands")
+	}
+}
+
+func TestBuildItermScript_SizesSplits(t *testing.T) {
+	plan := NewPlan(fakeBackend{}, Options{
+		ProjectDirs: []string{"/projects/api"},
+		RowCols:     []int{3, 3, 3},
+	})
+
+	script, err := buildItermScript(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		// Each new row or column takes its share of what is left: 2/3, then 1/2
+		"set total to (rows of s0) + (rows of s3)\n    tell s3 to set rows to (total * 66 / 100) as integer",
+		"set total to (rows of s3) + (rows of s6)\n    tell s6 to set rows to (total * 50 / 100) as integer",
+		"set total to (columns of s0) + (columns of s1)\n    tell s1 to set columns to (total * 66 / 100) as integer",
+		"set total to (columns of s1) + (columns of s2)\n    tell s2 to set columns to (total * 50 / 100) as integer",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script should contain %q:\n%s", want, script)
+		}
+	}
+	if n := strings.Count(script, "set total to"); n != 8 {
+		t.Errorf("got %d resizes, want one per split (8)", n)
+	}
+}
@@ -0,0 +1,73 @@
+package launcher
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// kittyBackend builds the grid in a new kitty tab over remote control.
+// kitty must run with allow_remote_control enabled.
+type kittyBackend struct{}
+
+func (kittyBackend) Name() string { return "kitty" }
+
+func (kittyBackend) DetectBounds() (Rect, error) { return terminalSize() }
+
+func (kittyBackend) Script(p Plan) (string, error) { return kittyScript(p) }
+
+func (kittyBackend) Run(p Plan) error {
+	script, err := kittyScript(p)
+	if err != nil {
+		return err
+	}
+	if out, err := exec.Command("sh", "-c", script).CombinedOutput(); err != nil {
+		return fmt.Errorf("kitty error: %w\noutput: %s", err, string(out))
+	}
+	return nil
+}
+
+// kittyScript renders the `kitty @` commands for the plan as a sh script.
+// The tab switches to the splits layout, and each new window is launched
+// next to the window it splits, whose id is kept in a shell variable.
+func kittyScript(p Plan) (string, error) {
+	if len(p.Cells) == 0 {
+		return "", fmt.Errorf("kitty: empty layout")
+	}
+
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	fmt.Fprintf(&b, "w0=$(kitty @ launch --type=tab --tab-title %s%s --cwd %s%s)\n",
+		shellQuote(workspaceName(p)), kittyTitle(p.Cells[0]), shellQuote(p.Cells[0].Dir), programArgs(p.Cells[0]))
+	b.WriteString("kitty @ goto-layout --match \"window_id:$w0\" splits\n")
+
+	for _, sp := range p.splits() {
+		location := "vsplit"
+		if sp.Below {
+			location = "hsplit"
+		}
+		c := p.Cells[sp.To]
+		fmt.Fprintf(&b, "w%d=$(kitty @ launch --location=%s --next-to \"id:$w%d\" --bias %d%s --cwd %s%s)\n",
+			sp.To, location, sp.From, sp.Percent, kittyTitle(c), shellQuote(c.Dir), programArgs(c))
+	}
+	b.WriteString("kitty @ focus-window --match \"id:$w0\"\n")
+	return b.String(), nil
+}
+
+// kittyTitle returns the --title option for a cell with a title.
+func kittyTitle(c Cell) string {
+	if c.Title == "" {
+		return ""
+	}
+	return " --title " + shellQuote(c.Title)
+}
+
+// programArgs returns the trailing `sh -c ...` arguments that start a cell's
+// program, or an empty string for a plain shell.
+func programArgs(c Cell) string {
+	prog := c.Program()
+	if prog == "" {
+		return ""
+	}
+	return " sh -c " + shellQuote(prog)
+}
@@ -0,0 +1,58 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKittyScript_SingleProject(t *testing.T) {
+	plan := NewPlan(kittyBackend{}, Options{
+		ProjectDirs: []string{"/projects/api"},
+		RowCols:     []int{2, 2},
+		Commands:    []string{"claude", "claude"},
+	})
+
+	script, err := kittyScript(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(script, "kitty @ launch --type=tab --tab-title 'agent-t-api'") {
+		t.Error("script should open a tab named after the project")
+	}
+	if !strings.Contains(script, "goto-layout --match \"window_id:$w0\" splits") {
+		t.Error("script should switch the tab to the splits layout")
+	}
+	if n := strings.Count(script, "--location=hsplit"); n != 1 {
+		t.Errorf("got %d row splits, want 1", n)
+	}
+	if n := strings.Count(script, "--location=vsplit"); n != 2 {
+		t.Errorf("got %d column splits, want 2", n)
+	}
+	if n := strings.Count(script, `sh -c 'claude; exec "${SHELL:-sh}"'`); n != 4 {
+		t.Errorf("got %d windows running claude, want 4", n)
+	}
+	if !strings.Contains(script, "w3=$(kitty @ launch --location=vsplit --next-to \"id:$w2\"") {
+		t.Errorf("bottom-right window should split the bottom-left one:\n%s", script)
+	}
+}
+
+func TestKittyScript_SplitProjects(t *testing.T) {
+	plan := NewPlan(kittyBackend{}, Options{
+		ProjectDirs: []string{"/projects/api", "/projects/frontend"},
+		RowCols:     []int{3, 3},
+		Commands:    []string{"claude", ""},
+	})
+
+	script, err := kittyScript(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := strings.Count(script, "--cwd '/projects/frontend')"); n != 3 {
+		t.Errorf("got %d plain bottom windows, want 3", n)
+	}
+	if n := strings.Count(script, "--cwd '/projects/api' sh -c"); n != 3 {
+		t.Errorf("got %d top windows running a tool, want 3", n)
+	}
+}
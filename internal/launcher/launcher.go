@@ -1,132 +1,241 @@
 package launcher
 
 import (
-	"bytes"
 	"fmt"
-	"os/exec"
-	"strconv"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
-	"text/template"
+	"text/tabwriter"
 )
 
 type Options struct {
-	ProjectDirs []string // one project dir per row
-	RowCols     []int    // columns per row, e.g. [3,4] = 3 top, 4 bottom
-	Commands    []string // one tool command per row (empty string = no tool)
+	ProjectDirs []string   // one project dir per row
+	RowCols     []int      // columns per row, e.g. [3,4] = 3 top, 4 bottom
+	Commands    []string   // one tool command per row (empty string = no tool)
+	Cells       []CellSpec // per-cell assignments in row-major order; when set, replaces ProjectDirs and Commands
+	Backend     string     // backend name, empty = DefaultBackend
 }
 
-type screenBounds struct {
-	X1, Y1, X2, Y2 int
+// CellSpec is what a single cell runs. A per-row workspace is the special
+// case where every cell of a row has the same spec.
+type CellSpec struct {
+	Dir     string
+	Command string            // tool command (empty string = no tool)
+	Title   string            // pane or window title, empty = terminal default
+	Env     map[string]string // exported in the cell's shell before Command
 }
 
-type scriptData struct {
+// Rect is a screen rectangle; X2/Y2 are exclusive edges, not sizes.
+type Rect struct {
 	X1, Y1, X2, Y2 int
-	RowCols         string // comma-separated, e.g. "3,4"
-	NumRows         int
-	TermCmds        string // AppleScript list literal, e.g. "\"cd ... && claude\", \"cd ... && codex\""
 }
 
-func Launch(opts Options) error {
-	bounds, err := detectScreen()
-	if err != nil {
-		// Fallback to 1920x1080
-		bounds = screenBounds{X1: 0, Y1: 0, X2: 1920, Y2: 1080}
+func (r Rect) String() string {
+	return fmt.Sprintf("(%d,%d)-(%d,%d)", r.X1, r.Y1, r.X2, r.Y2)
+}
+
+// Cell is one terminal in the grid, addressed by zero-based row and column.
+type Cell struct {
+	Row, Col int
+	Rect     Rect
+	CellSpec
+}
+
+// ShellLine is the command line typed into the cell's shell.
+func (c Cell) ShellLine() string {
+	line := fmt.Sprintf("cd %s", shellQuote(c.Dir))
+	if exports := c.exports(); exports != "" {
+		line += " && " + exports
+	}
+	line += " && clear"
+	if c.Command != "" {
+		line += " && " + c.Command
 	}
+	return line
+}
 
-	// Build per-row terminal commands
-	numRows := len(opts.RowCols)
-	termCmds := make([]string, numRows)
-	for r := 0; r < numRows; r++ {
-		dir := opts.ProjectDirs[0]
-		if r < len(opts.ProjectDirs) {
-			dir = opts.ProjectDirs[r]
-		}
-		cmd := ""
-		if r < len(opts.Commands) {
-			cmd = opts.Commands[r]
-		}
-		termCmds[r] = fmt.Sprintf("cd %s && clear", shellQuote(dir))
-		if cmd != "" {
-			termCmds[r] += " && " + cmd
+// envName is what a shell accepts as a variable name.
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidEnvName reports whether name can be exported from a shell. Other
+// names are rejected, since they are written into the cell's command line.
+func ValidEnvName(name string) bool {
+	return envName.MatchString(name)
+}
+
+// exports renders Env as a single `export` statement with sorted keys.
+// Names are not quoted, so those that are not valid variable names are
+// left out; Launch refuses them.
+func (c Cell) exports() string {
+	var keys []string
+	for k := range c.Env {
+		if ValidEnvName(k) {
+			keys = append(keys, k)
 		}
 	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + shellQuote(c.Env[k])
+	}
+	return "export " + strings.Join(parts, " ")
+}
 
-	script, err := buildTilingScript(bounds, opts.RowCols, termCmds)
-	if err != nil {
-		return fmt.Errorf("building AppleScript: %w", err)
+// Program is the body of an `sh -c` program that starts the cell, for
+// backends that launch it as the pane's program rather than typing into a
+// shell. The pane is handed over to the user's shell once the tool exits.
+// It is empty when the cell is a plain shell.
+func (c Cell) Program() string {
+	var parts []string
+	if exports := c.exports(); exports != "" {
+		parts = append(parts, exports)
+	}
+	if c.Command != "" {
+		parts = append(parts, c.Command)
+	}
+	if len(parts) == 0 {
+		return ""
 	}
+	return strings.Join(append(parts, "exec \"${SHELL:-sh}\""), "; ")
+}
 
-	return execAppleScript(script)
+// Plan is a fully resolved grid, ready to hand to a Backend.
+type Plan struct {
+	Bounds  Rect
+	RowCols []int
+	Cells   []Cell // row-major order
 }
 
-func detectScreen() (screenBounds, error) {
-	cmd := exec.Command("osascript", "-l", "JavaScript", "-e", jxaScreenDetect)
-	out, err := cmd.Output()
+// fallbackBounds is used when a backend cannot detect its screen.
+var fallbackBounds = Rect{X1: 0, Y1: 0, X2: 1920, Y2: 1080}
+
+func Launch(opts Options) error {
+	for i, c := range opts.Cells {
+		for k := range c.Env {
+			if !ValidEnvName(k) {
+				return fmt.Errorf("cell %d: env name %q is not a valid variable name", i+1, k)
+			}
+		}
+	}
+	b, err := NewBackend(opts.Backend)
 	if err != nil {
-		return screenBounds{}, fmt.Errorf("screen detection failed: %w", err)
+		return err
 	}
+	return b.Run(NewPlan(b, opts))
+}
 
-	parts := strings.Fields(strings.TrimSpace(string(out)))
-	if len(parts) != 4 {
-		return screenBounds{}, fmt.Errorf("unexpected screen detection output: %q", string(out))
+// NewPlan resolves opts into cells positioned inside the bounds b detects.
+func NewPlan(b Backend, opts Options) Plan {
+	bounds, err := b.DetectBounds()
+	if err != nil {
+		bounds = fallbackBounds
 	}
 
-	vals := make([]int, 4)
-	for i, p := range parts {
-		v, err := strconv.Atoi(p)
-		if err != nil {
-			return screenBounds{}, fmt.Errorf("parsing screen bound %q: %w", p, err)
+	plan := Plan{Bounds: bounds, RowCols: opts.RowCols}
+	numRows := len(opts.RowCols)
+	cellH := (bounds.Y2 - bounds.Y1) / max(numRows, 1)
+	for r, cols := range opts.RowCols {
+		row := CellSpec{}
+		if len(opts.ProjectDirs) > 0 {
+			row.Dir = opts.ProjectDirs[0]
+		}
+		if r < len(opts.ProjectDirs) {
+			row.Dir = opts.ProjectDirs[r]
+		}
+		if r < len(opts.Commands) {
+			row.Command = opts.Commands[r]
+		}
+		cellW := (bounds.X2 - bounds.X1) / max(cols, 1)
+		for c := 0; c < cols; c++ {
+			spec := row
+			if i := len(plan.Cells); i < len(opts.Cells) {
+				spec = opts.Cells[i]
+			}
+			plan.Cells = append(plan.Cells, Cell{
+				Row: r,
+				Col: c,
+				Rect: Rect{
+					X1: bounds.X1 + c*cellW,
+					Y1: bounds.Y1 + r*cellH,
+					X2: bounds.X1 + (c+1)*cellW,
+					Y2: bounds.Y1 + (r+1)*cellH,
+				},
+				CellSpec: spec,
+			})
 		}
-		vals[i] = v
 	}
+	return plan
+}
 
-	return screenBounds{X1: vals[0], Y1: vals[1], X2: vals[2], Y2: vals[3]}, nil
+// Describe renders the plan as a table with one line per cell: its grid
+// position, rectangle, directory, command (with any environment) and title.
+func (p Plan) Describe(backend string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Backend: %s\n", backend)
+	fmt.Fprintf(&b, "Bounds:  %s\n\n", p.Bounds)
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CELL\tRECT\tDIRECTORY\tCOMMAND\tTITLE")
+	for _, c := range p.Cells {
+		cmd := c.Command
+		if cmd == "" {
+			cmd = "-"
+		}
+		if exports := c.exports(); exports != "" {
+			cmd = strings.TrimPrefix(exports, "export ") + " " + cmd
+		}
+		title := c.Title
+		if title == "" {
+			title = "-"
+		}
+		fmt.Fprintf(tw, "%d,%d\t%s\t%s\t%s\t%s\n", c.Row+1, c.Col+1, c.Rect, c.Dir, cmd, title)
+	}
+	tw.Flush()
+	return b.String()
 }
 
-func buildTilingScript(bounds screenBounds, rowCols []int, termCmds []string) (string, error) {
-	tmpl, err := template.New("tiling").Parse(tilingScriptTemplate)
-	if err != nil {
-		return "", err
-	}
-
-	// Build comma-separated rowCols string for AppleScript
-	parts := make([]string, len(rowCols))
-	for i, c := range rowCols {
-		parts[i] = strconv.Itoa(c)
-	}
-
-	// Build AppleScript list literal for per-row commands
-	// Each command is double-quote-escaped for AppleScript string embedding
-	cmdParts := make([]string, len(termCmds))
-	for i, cmd := range termCmds {
-		escaped := strings.ReplaceAll(cmd, "\\", "\\\\")
-		escaped = strings.ReplaceAll(escaped, "\"", "\\\"")
-		cmdParts[i] = fmt.Sprintf("\"%s\"", escaped)
-	}
-
-	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, scriptData{
-		X1:       bounds.X1,
-		Y1:       bounds.Y1,
-		X2:       bounds.X2,
-		Y2:       bounds.Y2,
-		RowCols:  strings.Join(parts, ", "),
-		NumRows:  len(rowCols),
-		TermCmds: strings.Join(cmdParts, ", "),
-	})
-	if err != nil {
-		return "", err
+// workspaceName names the session, tab or window after the first cell's
+// project. '.' and ':' are replaced since tmux rejects them in session names.
+func workspaceName(p Plan) string {
+	name := "agent-t"
+	if len(p.Cells) > 0 {
+		name += "-" + filepath.Base(p.Cells[0].Dir)
 	}
+	return strings.NewReplacer(".", "-", ":", "-").Replace(name)
+}
 
-	return buf.String(), nil
+// split is one step in carving a single pane into the grid: the pane of
+// cell From is divided and the new pane, which becomes cell To, takes
+// Percent of its space either below it or to its right.
+type split struct {
+	From, To int // indexes into Plan.Cells
+	Below    bool
+	Percent  int
 }
 
-func execAppleScript(script string) error {
-	cmd := exec.Command("osascript", "-e", script)
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("osascript error: %w\noutput: %s", err, string(out))
+// splits returns the steps that turn the first cell's pane into the whole
+// grid. Rows are split off top to bottom first, then each row is split
+// into its columns; each new pane takes the share of what remains, so all
+// rows and all columns within a row end up the same size.
+func (p Plan) splits() []split {
+	var steps []split
+	rowStart := make([]int, len(p.RowCols))
+	for r := 1; r < len(p.RowCols); r++ {
+		rowStart[r] = rowStart[r-1] + p.RowCols[r-1]
+		left := len(p.RowCols) - r
+		steps = append(steps, split{From: rowStart[r-1], To: rowStart[r], Below: true, Percent: left * 100 / (left + 1)})
+	}
+	for r, cols := range p.RowCols {
+		for c := 1; c < cols; c++ {
+			left := cols - c
+			steps = append(steps, split{From: rowStart[r] + c - 1, To: rowStart[r] + c, Percent: left * 100 / (left + 1)})
+		}
 	}
-	return nil
+	return steps
 }
 
 // shellQuote wraps a string in single quotes for safe shell embedding.
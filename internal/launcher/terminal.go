@@ -0,0 +1,141 @@
+package launcher
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// menuBarHeight is the strip at the top of the screen Terminal windows avoid.
+const menuBarHeight = 25
+
+// terminalBackend tiles one Terminal.app window per cell via AppleScript.
+type terminalBackend struct{}
+
+type scriptData struct {
+	X1, Y1, X2, Y2 int
+	RowCols        string // comma-separated, e.g. "3,4"
+	NumRows        int
+	TermCmds       string // AppleScript list literal, one per cell, e.g. "\"cd ... && claude\", \"cd ... && codex\""
+	TermTitles     string // AppleScript list literal, one per cell ("" = no custom title)
+}
+
+func (terminalBackend) Name() string { return "terminal" }
+
+func (terminalBackend) DetectBounds() (Rect, error) {
+	bounds, err := detectScreen("Terminal")
+	if err != nil {
+		bounds = fallbackBounds
+	}
+	bounds.Y1 += menuBarHeight
+	return bounds, nil
+}
+
+func (terminalBackend) Script(p Plan) (string, error) {
+	termCmds := make([]string, len(p.Cells))
+	termTitles := make([]string, len(p.Cells))
+	for i, c := range p.Cells {
+		termCmds[i] = c.ShellLine()
+		termTitles[i] = c.Title
+	}
+	script, err := buildTilingScript(p.Bounds, p.RowCols, termCmds, termTitles)
+	if err != nil {
+		return "", fmt.Errorf("building AppleScript: %w", err)
+	}
+	return script, nil
+}
+
+func (t terminalBackend) Run(p Plan) error {
+	script, err := t.Script(p)
+	if err != nil {
+		return err
+	}
+	return execAppleScript(script)
+}
+
+// detectScreen returns the bounds of the screen showing app's front window.
+func detectScreen(app string) (Rect, error) {
+	cmd := exec.Command("osascript", "-l", "JavaScript", "-e", fmt.Sprintf(jxaScreenDetect, app))
+	out, err := cmd.Output()
+	if err != nil {
+		return Rect{}, fmt.Errorf("screen detection failed: %w", err)
+	}
+
+	parts := strings.Fields(strings.TrimSpace(string(out)))
+	if len(parts) != 4 {
+		return Rect{}, fmt.Errorf("unexpected screen detection output: %q", string(out))
+	}
+
+	vals := make([]int, 4)
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return Rect{}, fmt.Errorf("parsing screen bound %q: %w", p, err)
+		}
+		vals[i] = v
+	}
+
+	return Rect{X1: vals[0], Y1: vals[1], X2: vals[2], Y2: vals[3]}, nil
+}
+
+// buildTilingScript renders the Terminal.app script. termCmds and termTitles
+// hold one entry per cell in row-major order; termTitles may be nil.
+func buildTilingScript(bounds Rect, rowCols []int, termCmds, termTitles []string) (string, error) {
+	tmpl, err := template.New("tiling").Parse(tilingScriptTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	// Build comma-separated rowCols string for AppleScript
+	parts := make([]string, len(rowCols))
+	for i, c := range rowCols {
+		parts[i] = strconv.Itoa(c)
+	}
+
+	// Build AppleScript list literals for per-cell commands and titles
+	cmdParts := make([]string, len(termCmds))
+	titleParts := make([]string, len(termCmds))
+	for i, cmd := range termCmds {
+		cmdParts[i] = appleScriptString(cmd)
+		title := ""
+		if i < len(termTitles) {
+			title = termTitles[i]
+		}
+		titleParts[i] = appleScriptString(title)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, scriptData{
+		X1:         bounds.X1,
+		Y1:         bounds.Y1,
+		X2:         bounds.X2,
+		Y2:         bounds.Y2,
+		RowCols:    strings.Join(parts, ", "),
+		NumRows:    len(rowCols),
+		TermCmds:   strings.Join(cmdParts, ", "),
+		TermTitles: strings.Join(titleParts, ", "),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// appleScriptString returns s as a double-quoted AppleScript string literal.
+func appleScriptString(s string) string {
+	escaped := strings.ReplaceAll(s, "\\", "\\\\")
+	escaped = strings.ReplaceAll(escaped, "\"", "\\\"")
+	return "\"" + escaped + "\""
+}
+
+func execAppleScript(script string) error {
+	cmd := exec.Command("osascript", "-e", script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("osascript error: %w\noutput: %s", err, string(out))
+	}
+	return nil
+}
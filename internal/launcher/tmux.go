@@ -0,0 +1,115 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// tmuxDefaultSize is the session size used when the current terminal size
+// cannot be read. tmux rescales the panes proportionally on attach.
+var tmuxDefaultSize = Rect{X1: 0, Y1: 0, X2: 200, Y2: 50}
+
+// tmuxBackend builds a tmux session whose panes reproduce the grid.
+type tmuxBackend struct {
+	socket   string // tmux -L socket name, empty = default server
+	detached bool   // create the session without attaching to it
+}
+
+func (tmuxBackend) Name() string { return "tmux" }
+
+// DetectBounds reports the current terminal size in character cells.
+func (tmuxBackend) DetectBounds() (Rect, error) {
+	bounds, err := terminalSize()
+	if err != nil {
+		return tmuxDefaultSize, nil
+	}
+	return bounds, nil
+}
+
+func (t tmuxBackend) Script(p Plan) (string, error) {
+	return t.buildScript(p, workspaceName(p))
+}
+
+func (t tmuxBackend) Run(p Plan) error {
+	script, err := t.buildScript(p, t.freeSessionName(workspaceName(p)))
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("tmux error: %w", err)
+	}
+	return nil
+}
+
+// buildScript renders a POSIX sh script that creates the session. Each new
+// pane's id is captured in a shell variable so later splits can target it
+// regardless of the user's pane-base-index.
+func (t tmuxBackend) buildScript(p Plan, session string) (string, error) {
+	if len(p.Cells) == 0 {
+		return "", fmt.Errorf("tmux: empty layout")
+	}
+
+	tmux := "tmux"
+	if t.socket != "" {
+		tmux += " -L " + shellQuote(t.socket)
+	}
+
+	var b strings.Builder
+	b.WriteString("set -e\n")
+
+	fmt.Fprintf(&b, "p0=$(%s new-session -d -s %s -x %d -y %d -c %s -P -F '#{pane_id}')\n",
+		tmux, shellQuote(session),
+		p.Bounds.X2-p.Bounds.X1, p.Bounds.Y2-p.Bounds.Y1, shellQuote(p.Cells[0].Dir))
+
+	for _, sp := range p.splits() {
+		dir := "-h"
+		if sp.Below {
+			dir = "-v"
+		}
+		fmt.Fprintf(&b, "p%d=$(%s split-window %s -t \"$p%d\" -l %d%% -c %s -P -F '#{pane_id}')\n",
+			sp.To, tmux, dir, sp.From, sp.Percent, shellQuote(p.Cells[sp.To].Dir))
+	}
+
+	for i, c := range p.Cells {
+		if c.Title != "" {
+			fmt.Fprintf(&b, "%s select-pane -t \"$p%d\" -T %s\n", tmux, i, shellQuote(c.Title))
+		}
+		fmt.Fprintf(&b, "%s send-keys -t \"$p%d\" %s Enter\n", tmux, i, shellQuote(c.ShellLine()))
+	}
+	fmt.Fprintf(&b, "%s select-pane -t \"$p0\"\n", tmux)
+
+	if !t.detached {
+		if os.Getenv("TMUX") != "" {
+			fmt.Fprintf(&b, "%s switch-client -t %s\n", tmux, shellQuote(session))
+		} else {
+			fmt.Fprintf(&b, "%s attach-session -t %s\n", tmux, shellQuote(session))
+		}
+	}
+
+	return b.String(), nil
+}
+
+// freeSessionName appends a numeric suffix to base until no session with
+// that name exists on the server.
+func (t tmuxBackend) freeSessionName(base string) string {
+	name := base
+	for i := 2; t.hasSession(name); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+func (t tmuxBackend) hasSession(name string) bool {
+	var args []string
+	if t.socket != "" {
+		args = append(args, "-L", t.socket)
+	}
+	args = append(args, "has-session", "-t", "="+name)
+	return exec.Command("tmux", args...).Run() == nil
+}
@@ -0,0 +1,147 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func tmuxTestPlan(rowCols []int, dirs []string, cmds []string) Plan {
+	return NewPlan(tmuxBackend{}, Options{ProjectDirs: dirs, RowCols: rowCols, Commands: cmds})
+}
+
+func TestTmuxScript_Structure(t *testing.T) {
+	plan := tmuxTestPlan([]int{3, 2}, []string{"/projects/api", "/projects/web"}, []string{"claude", "codex"})
+
+	script, err := tmuxBackend{detached: true}.Script(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := strings.Count(script, "new-session"); n != 1 {
+		t.Errorf("got %d new-session commands, want 1", n)
+	}
+	if n := strings.Count(script, "split-window -v"); n != 1 {
+		t.Errorf("got %d row splits, want 1", n)
+	}
+	if n := strings.Count(script, "split-window -h"); n != 3 {
+		t.Errorf("got %d column splits, want 3", n)
+	}
+	if n := strings.Count(script, "send-keys"); n != 5 {
+		t.Errorf("got %d send-keys, want one per cell (5)", n)
+	}
+	if !strings.Contains(script, "-s 'agent-t-api'") {
+		t.Error("session should be named after the first project")
+	}
+	if !strings.Contains(script, "-c '/projects/web'") {
+		t.Error("bottom row panes should start in the bottom project")
+	}
+	if !strings.Contains(script, "'cd '\\''/projects/web'\\'' && clear && codex'") {
+		t.Error("script missing quoted bottom row command")
+	}
+	if strings.Contains(script, "attach-session") {
+		t.Error("detached script should not attach")
+	}
+}
+
+func TestTmuxScript_CellTitles(t *testing.T) {
+	plan := NewPlan(tmuxBackend{}, Options{
+		RowCols: []int{2},
+		Cells: []CellSpec{
+			{Dir: "/projects/api", Command: "claude"},
+			{Dir: "/projects/api", Command: "npm run dev", Title: "dev server"},
+		},
+	})
+
+	script, err := tmuxBackend{detached: true}.Script(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := strings.Count(script, "-T "); n != 1 {
+		t.Errorf("got %d pane titles, want 1", n)
+	}
+	if !strings.Contains(script, `select-pane -t "$p1" -T 'dev server'`) {
+		t.Errorf("script missing title for second pane:\n%s", script)
+	}
+}
+
+func TestTmuxScript_EqualShares(t *testing.T) {
+	plan := tmuxTestPlan([]int{1, 1, 1, 1}, []string{"/p"}, nil)
+
+	script, err := tmuxBackend{detached: true}.Script(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"-l 75%", "-l 66%", "-l 50%"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing split %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestWorkspaceName(t *testing.T) {
+	plan := tmuxTestPlan([]int{2}, []string{"/projects/my.app"}, nil)
+	if got := workspaceName(plan); got != "agent-t-my-app" {
+		t.Errorf("workspaceName() = %q, want %q", got, "agent-t-my-app")
+	}
+}
+
+// TestTmuxBackend_Live builds a real session on a private tmux server and
+// checks that its panes form the requested grid.
+func TestTmuxBackend_Live(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+
+	socket := fmt.Sprintf("agent-t-test-%d", os.Getpid())
+	t.Cleanup(func() { exec.Command("tmux", "-L", socket, "kill-server").Run() })
+
+	top, bottom := t.TempDir(), t.TempDir()
+	b := tmuxBackend{socket: socket, detached: true}
+	plan := NewPlan(b, Options{ProjectDirs: []string{top, bottom}, RowCols: []int{3, 2}})
+	plan.Bounds = tmuxDefaultSize
+	if err := b.Run(plan); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	out, err := exec.Command("tmux", "-L", socket, "list-panes", "-t", workspaceName(plan),
+		"-F", "#{pane_top} #{pane_current_path}").Output()
+	if err != nil {
+		t.Fatalf("list-panes: %v", err)
+	}
+
+	// Group panes by their top edge: one group per row, in screen order.
+	rows := map[int][]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, " ", 2)
+		y, _ := strconv.Atoi(fields[0])
+		rows[y] = append(rows[y], fields[1])
+	}
+	var tops []int
+	for y := range rows {
+		tops = append(tops, y)
+	}
+	sort.Ints(tops)
+
+	if len(tops) != 2 {
+		t.Fatalf("got %d rows of panes, want 2: %s", len(tops), out)
+	}
+	wantDirs := []string{top, bottom}
+	for i, y := range tops {
+		if got := len(rows[y]); got != plan.RowCols[i] {
+			t.Errorf("row %d has %d panes, want %d", i, got, plan.RowCols[i])
+		}
+		for _, dir := range rows[y] {
+			want, _ := filepath.EvalSymlinks(wantDirs[i])
+			got, _ := filepath.EvalSymlinks(dir)
+			if got != want {
+				t.Errorf("row %d pane in %q, want %q", i, dir, wantDirs[i])
+			}
+		}
+	}
+}
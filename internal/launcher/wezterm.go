@@ -0,0 +1,64 @@
+package launcher
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// weztermBackend builds the grid in a new WezTerm window with `wezterm cli`.
+type weztermBackend struct{}
+
+func (weztermBackend) Name() string { return "wezterm" }
+
+func (weztermBackend) DetectBounds() (Rect, error) { return terminalSize() }
+
+func (weztermBackend) Script(p Plan) (string, error) { return weztermScript(p) }
+
+func (weztermBackend) Run(p Plan) error {
+	script, err := weztermScript(p)
+	if err != nil {
+		return err
+	}
+	if out, err := exec.Command("sh", "-c", script).CombinedOutput(); err != nil {
+		return fmt.Errorf("wezterm error: %w\noutput: %s", err, string(out))
+	}
+	return nil
+}
+
+// weztermScript renders the `wezterm cli` commands for the plan as a sh
+// script. Each spawned pane's id is kept in a shell variable so later
+// splits can target it. wezterm cli has no per-pane titles, so cell
+// titles are not applied.
+func weztermScript(p Plan) (string, error) {
+	if len(p.Cells) == 0 {
+		return "", fmt.Errorf("wezterm: empty layout")
+	}
+
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	fmt.Fprintf(&b, "p0=$(wezterm cli spawn --new-window --cwd %s%s)\n",
+		shellQuote(p.Cells[0].Dir), weztermProgram(p.Cells[0]))
+
+	for _, sp := range p.splits() {
+		side := "--right"
+		if sp.Below {
+			side = "--bottom"
+		}
+		c := p.Cells[sp.To]
+		fmt.Fprintf(&b, "p%d=$(wezterm cli split-pane --pane-id \"$p%d\" %s --percent %d --cwd %s%s)\n",
+			sp.To, sp.From, side, sp.Percent, shellQuote(c.Dir), weztermProgram(c))
+	}
+	fmt.Fprintf(&b, "wezterm cli set-tab-title --pane-id \"$p0\" %s\n", shellQuote(workspaceName(p)))
+	b.WriteString("wezterm cli activate-pane --pane-id \"$p0\"\n")
+	return b.String(), nil
+}
+
+// weztermProgram is programArgs behind the `--` wezterm cli requires.
+func weztermProgram(c Cell) string {
+	args := programArgs(c)
+	if args == "" {
+		return ""
+	}
+	return " --" + args
+}
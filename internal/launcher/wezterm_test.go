@@ -0,0 +1,57 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWeztermScript_SingleProject(t *testing.T) {
+	plan := NewPlan(weztermBackend{}, Options{
+		ProjectDirs: []string{"/projects/api"},
+		RowCols:     []int{3},
+		Commands:    []string{"codex"},
+	})
+
+	script, err := weztermScript(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(script, "p0=$(wezterm cli spawn --new-window --cwd '/projects/api' -- sh -c") {
+		t.Errorf("script should spawn the first pane in a new window:\n%s", script)
+	}
+	if strings.Contains(script, "--bottom") {
+		t.Error("single-row layout should not split vertically")
+	}
+	for _, want := range []string{
+		"p1=$(wezterm cli split-pane --pane-id \"$p0\" --right --percent 66",
+		"p2=$(wezterm cli split-pane --pane-id \"$p1\" --right --percent 50",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestWeztermScript_SplitProjects(t *testing.T) {
+	plan := NewPlan(weztermBackend{}, Options{
+		ProjectDirs: []string{"/projects/api", "/projects/frontend"},
+		RowCols:     []int{2, 2},
+		Commands:    []string{"claude", "codex"},
+	})
+
+	script, err := weztermScript(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(script, "p2=$(wezterm cli split-pane --pane-id \"$p0\" --bottom --percent 50 --cwd '/projects/frontend'") {
+		t.Errorf("bottom row should be split off the first pane:\n%s", script)
+	}
+	if n := strings.Count(script, "codex"); n != 2 {
+		t.Errorf("got %d panes running codex, want 2", n)
+	}
+	if !strings.Contains(script, "set-tab-title --pane-id \"$p0\" 'agent-t-api'") {
+		t.Error("script should title the tab after the first project")
+	}
+}
@@ -0,0 +1,103 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// zellijBackend starts Zellij with a KDL layout generated from the grid.
+type zellijBackend struct{}
+
+func (zellijBackend) Name() string { return "zellij" }
+
+func (zellijBackend) DetectBounds() (Rect, error) { return terminalSize() }
+
+func (zellijBackend) Script(p Plan) (string, error) { return ZellijLayout(p) }
+
+func (zellijBackend) Run(p Plan) error {
+	layout, err := ZellijLayout(p)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", "agent-t-*.kdl")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(layout); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// Inside an existing Zellij session, open the grid as a new tab instead
+	// of nesting sessions.
+	var cmd *exec.Cmd
+	if os.Getenv("ZELLIJ") != "" {
+		cmd = exec.Command("zellij", "action", "new-tab", "--layout", f.Name())
+	} else {
+		cmd = exec.Command("zellij", "--layout", f.Name())
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("zellij error: %w", err)
+	}
+	return nil
+}
+
+// ZellijLayout renders the plan as a Zellij KDL layout: one horizontal split
+// per row, each row split vertically into its columns, between the default
+// tab and status bars. Cells with a tool or environment run Cell.Program
+// through sh, so a pane stays open after the tool exits.
+func ZellijLayout(p Plan) (string, error) {
+	if len(p.Cells) == 0 {
+		return "", fmt.Errorf("zellij: empty layout")
+	}
+
+	var b strings.Builder
+	b.WriteString("layout {\n")
+	b.WriteString("    pane size=1 borderless=true {\n")
+	b.WriteString("        plugin location=\"zellij:tab-bar\"\n")
+	b.WriteString("    }\n")
+	b.WriteString("    pane split_direction=\"horizontal\" {\n")
+	for r := range p.RowCols {
+		b.WriteString("        pane split_direction=\"vertical\" {\n")
+		for _, c := range p.Cells {
+			if c.Row != r {
+				continue
+			}
+			attrs := "cwd=" + kdlString(c.Dir)
+			if c.Title != "" {
+				attrs += " name=" + kdlString(c.Title)
+			}
+			prog := c.Program()
+			if prog == "" {
+				fmt.Fprintf(&b, "            pane %s\n", attrs)
+				continue
+			}
+			fmt.Fprintf(&b, "            pane %s command=\"sh\" {\n", attrs)
+			fmt.Fprintf(&b, "                args \"-c\" %s\n", kdlString(prog))
+			b.WriteString("            }\n")
+		}
+		b.WriteString("        }\n")
+	}
+	b.WriteString("    }\n")
+	b.WriteString("    pane size=2 borderless=true {\n")
+	b.WriteString("        plugin location=\"zellij:status-bar\"\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// kdlString returns s as a double-quoted KDL string literal.
+func kdlString(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n", "\t", "\\t")
+	return "\"" + r.Replace(s) + "\""
+}
@@ -0,0 +1,69 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestZellijLayout_Grid(t *testing.T) {
+	plan := NewPlan(zellijBackend{}, Options{
+		ProjectDirs: []string{"/projects/api", "/projects/web"},
+		RowCols:     []int{3, 2},
+		Commands:    []string{"claude", ""},
+	})
+
+	layout, err := ZellijLayout(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := strings.Count(layout, `split_direction="vertical"`); n != 2 {
+		t.Errorf("got %d row containers, want 2", n)
+	}
+	if n := strings.Count(layout, `cwd="/projects/api" command="sh"`); n != 3 {
+		t.Errorf("got %d top panes running a tool, want 3", n)
+	}
+	if n := strings.Count(layout, `pane cwd="/projects/web"`+"\n"); n != 2 {
+		t.Errorf("got %d plain bottom panes, want 2", n)
+	}
+	if !strings.Contains(layout, `args "-c" "claude; exec \"${SHELL:-sh}\""`) {
+		t.Errorf("layout missing tool command args:\n%s", layout)
+	}
+	if strings.Count(layout, "{") != strings.Count(layout, "}") {
+		t.Errorf("unbalanced braces:\n%s", layout)
+	}
+}
+
+func TestZellijLayout_CellTitleAndEnv(t *testing.T) {
+	plan := NewPlan(zellijBackend{}, Options{
+		RowCols: []int{1},
+		Cells:   []CellSpec{{Dir: "/p", Title: "shell", Env: map[string]string{"A": "1"}}},
+	})
+
+	layout, err := ZellijLayout(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(layout, `cwd="/p" name="shell" command="sh"`) {
+		t.Errorf("layout missing titled pane:\n%s", layout)
+	}
+	if !strings.Contains(layout, `args "-c" "export A='1'; exec \"${SHELL:-sh}\""`) {
+		t.Errorf("layout missing env exports:\n%s", layout)
+	}
+}
+
+func TestKDLString(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"simple", `"simple"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\dir`, `"C:\\dir"`},
+	}
+	for _, tt := range tests {
+		if got := kdlString(tt.input); got != tt.want {
+			t.Errorf("kdlString(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
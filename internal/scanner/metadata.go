@@ -0,0 +1,186 @@
+package scanner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitInfo is the state of a project's git checkout.
+type GitInfo struct {
+	Branch     string // "(detached)" when HEAD is not on a branch
+	Upstream   bool   // whether the branch tracks a remote branch
+	Ahead      int    // commits not on the upstream
+	Behind     int    // upstream commits not on the branch
+	Dirty      int    // changed, staged and untracked files
+	LastCommit time.Time
+}
+
+// maxLanguageFiles bounds how many files are looked at to guess a
+// project's language when no manifest gives it away.
+const maxLanguageFiles = 2000
+
+// manifests name the language of a project by a file in its top level,
+// checked in order.
+var manifests = []struct{ file, language string }{
+	{"go.mod", "Go"},
+	{"Cargo.toml", "Rust"},
+	{"tsconfig.json", "TypeScript"},
+	{"package.json", "JavaScript"},
+	{"pyproject.toml", "Python"},
+	{"setup.py", "Python"},
+	{"requirements.txt", "Python"},
+	{"Gemfile", "Ruby"},
+	{"mix.exs", "Elixir"},
+	{"pom.xml", "Java"},
+	{"build.gradle", "Java"},
+	{"build.gradle.kts", "Kotlin"},
+	{"Package.swift", "Swift"},
+	{"composer.json", "PHP"},
+	{"CMakeLists.txt", "C++"},
+}
+
+// extensions map source file extensions to languages for projects without
+// a manifest.
+var extensions = map[string]string{
+	".go":    "Go",
+	".rs":    "Rust",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".py":    "Python",
+	".rb":    "Ruby",
+	".ex":    "Elixir",
+	".exs":   "Elixir",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".swift": "Swift",
+	".php":   "PHP",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".cs":    "C#",
+	".lua":   "Lua",
+	".sh":    "Shell",
+	".zig":   "Zig",
+}
+
+// Inspect fills in the git state and language of projects, looking at up
+// to workers projects at a time. workers of 0 or less uses the number of
+// CPUs, at most 8.
+func Inspect(projects []Project, workers int) {
+	if workers <= 0 {
+		workers = min(runtime.NumCPU(), 8)
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(projects)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				projects[i].Git = gitInfo(projects[i].Path)
+				projects[i].Language = language(projects[i].Path)
+			}
+		}()
+	}
+	for i := range projects {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// gitInfo reads the state of the checkout at dir, or returns nil when dir
+// is not the top of a git checkout or git cannot be run.
+func gitInfo(dir string) *GitInfo {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return nil
+	}
+	out, err := exec.Command("git", "-C", dir, "status", "--porcelain=v2", "--branch").Output()
+	if err != nil {
+		return nil
+	}
+	info := parseStatus(string(out))
+
+	// Fails in a repository without commits, which leaves LastCommit zero
+	if out, err := exec.Command("git", "-C", dir, "log", "-1", "--format=%ct").Output(); err == nil {
+		if sec, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			info.LastCommit = time.Unix(sec, 0)
+		}
+	}
+	return info
+}
+
+// parseStatus reads the output of git status --porcelain=v2 --branch.
+func parseStatus(out string) *GitInfo {
+	info := &GitInfo{}
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# branch.head "):
+			info.Branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.upstream "):
+			info.Upstream = true
+		case strings.HasPrefix(line, "# branch.ab "):
+			for _, f := range strings.Fields(strings.TrimPrefix(line, "# branch.ab ")) {
+				n, _ := strconv.Atoi(f[1:])
+				if f[0] == '+' {
+					info.Ahead = n
+				} else {
+					info.Behind = n
+				}
+			}
+		case line[0] == '#':
+		default:
+			info.Dirty++
+		}
+	}
+	return info
+}
+
+// language guesses the primary language of the project at dir from its
+// manifest, or else from the most common source file extension.
+func language(dir string) string {
+	for _, m := range manifests {
+		if _, err := os.Stat(filepath.Join(dir, m.file)); err == nil {
+			return m.language
+		}
+	}
+
+	counts := make(map[string]int)
+	seen := 0
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && (d.Name()[0] == '.' || skipDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if seen++; seen > maxLanguageFiles {
+			return filepath.SkipAll
+		}
+		if lang, ok := extensions[strings.ToLower(filepath.Ext(d.Name()))]; ok {
+			counts[lang]++
+		}
+		return nil
+	})
+
+	best := ""
+	for lang, n := range counts {
+		if n > counts[best] || (n == counts[best] && lang < best) {
+			best = lang
+		}
+	}
+	return best
+}
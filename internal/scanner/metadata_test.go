@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	out := `# branch.oid 1234
+# branch.head main
+# branch.upstream origin/main
+# branch.ab +2 -5
+1 .M N... 100644 100644 100644 aaa bbb go.mod
+? notes.txt
+`
+	info := parseStatus(out)
+	if info.Branch != "main" || !info.Upstream || info.Ahead != 2 || info.Behind != 5 || info.Dirty != 2 {
+		t.Errorf("parseStatus = %+v", info)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		paths []string
+		want  string
+	}{
+		{[]string{"go.mod", "web/app.ts"}, "Go"},
+		{[]string{"package.json", "tsconfig.json"}, "TypeScript"},
+		{[]string{"src/a.py", "src/b.py", "run.sh"}, "Python"},
+		{[]string{"a.sh", "node_modules/x/a.js", "node_modules/x/b.js"}, "Shell"},
+		{[]string{"README.md"}, ""},
+	}
+	for _, tt := range tests {
+		if got := language(makeTree(t, tt.paths...)); got != tt.want {
+			t.Errorf("language(%v) = %q, want %q", tt.paths, got, tt.want)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	base := makeTree(t, "repo/main.go", "plain/lib.rs")
+	repo := filepath.Join(base, "repo")
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "trunk"},
+		{"add", "."},
+		{"commit", "-q", "-m", "initial"},
+	} {
+		args = append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repo, "new.go"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	projects, err := Scan(base, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Inspect(projects, 2)
+
+	plain, r := projects[0], projects[1]
+	if plain.Git != nil || plain.Language != "Rust" {
+		t.Errorf("plain = %+v, want no git state and Rust", plain)
+	}
+	if r.Language != "Go" || r.Git == nil {
+		t.Fatalf("repo = %+v, want Go and git state", r)
+	}
+	if r.Git.Branch != "trunk" || r.Git.Dirty != 1 || r.Git.Upstream || r.Git.LastCommit.IsZero() {
+		t.Errorf("repo git state = %+v", *r.Git)
+	}
+}
@@ -1,36 +1,154 @@
 package scanner
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+
+	"agent-t/internal/config"
 )
 
 type Project struct {
-	Name string
+	Name string // path relative to the scanned directory, e.g. "org/repo"
 	Path string
+	Root string // the scanned directory the project was found in
+
+	// ConfigFile is the path of the project's config.ProjectFile, empty
+	// when it has none
+	ConfigFile string
+
+	// Filled in by Inspect
+	Git      *GitInfo // nil when the project is not a git checkout
+	Language string   // primary language, empty when unknown
+}
+
+// Options controls how Scan looks for projects.
+type Options struct {
+	// MaxDepth is how many directory levels below the base are searched.
+	// At 1 or less every immediate, non-hidden subdirectory is a project.
+	// Deeper scans only list directories that contain one of Markers and
+	// do not descend into them.
+	MaxDepth int
 }
 
-func Scan(baseDir string) ([]Project, error) {
+// Markers are the files and directories that make a directory a project
+// in a recursive scan.
+var Markers = []string{".git", config.ProjectFile, "go.mod", "package.json", "Cargo.toml", "pyproject.toml"}
+
+// skipDirs are never descended into by a recursive scan.
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+}
+
+func Scan(baseDir string, opts Options) ([]Project, error) {
 	entries, err := os.ReadDir(baseDir)
 	if err != nil {
 		return nil, err
 	}
 
 	var projects []Project
-	for _, e := range entries {
-		if !e.IsDir() || e.Name()[0] == '.' {
+	if opts.MaxDepth <= 1 {
+		for _, e := range entries {
+			if !e.IsDir() || e.Name()[0] == '.' {
+				continue
+			}
+			projects = append(projects, Project{
+				Name: e.Name(),
+				Path: filepath.Join(baseDir, e.Name()),
+				Root: baseDir,
+			})
+		}
+	} else {
+		projects = walk(baseDir, "", entries, opts.MaxDepth, projects)
+		for i := range projects {
+			projects[i].Root = baseDir
+		}
+	}
+
+	for i := range projects {
+		file := filepath.Join(projects[i].Path, config.ProjectFile)
+		if _, err := os.Stat(file); err == nil {
+			projects[i].ConfigFile = file
+		}
+	}
+
+	sortProjects(projects)
+	return projects, nil
+}
+
+// ScanRoots scans each root in turn and merges the results. Roots are
+// made absolute, so projects have clean absolute paths, and a project
+// found under more than one root, or through different spellings of a
+// root, is listed once, for the first root. Roots that cannot be scanned
+// are reported in the error while the projects of the others are still
+// returned.
+func ScanRoots(roots []string, opts Options) ([]Project, error) {
+	var projects []Project
+	var errs []error
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("scanning %s: %w", root, err))
+			continue
+		}
+		found, err := Scan(abs, opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("scanning %s: %w", root, err))
 			continue
 		}
-		projects = append(projects, Project{
-			Name: e.Name(),
-			Path: filepath.Join(baseDir, e.Name()),
-		})
+		for _, p := range found {
+			if seen[p.Path] {
+				continue
+			}
+			seen[p.Path] = true
+			projects = append(projects, p)
+		}
 	}
+	sortProjects(projects)
+	return projects, errors.Join(errs...)
+}
 
-	sort.Slice(projects, func(i, j int) bool {
+func sortProjects(projects []Project) {
+	sort.SliceStable(projects, func(i, j int) bool {
 		return projects[i].Name < projects[j].Name
 	})
+}
 
-	return projects, nil
+// walk collects the projects among entries, the contents of dir, and
+// searches the other subdirectories down to depth more levels. Directories
+// that cannot be read are skipped.
+func walk(dir, rel string, entries []os.DirEntry, depth int, projects []Project) []Project {
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || name[0] == '.' || skipDirs[name] {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		relName := filepath.Join(rel, name)
+		if hasMarker(path) {
+			projects = append(projects, Project{Name: filepath.ToSlash(relName), Path: path})
+			continue
+		}
+		if depth > 1 {
+			sub, err := os.ReadDir(path)
+			if err != nil {
+				continue
+			}
+			projects = walk(path, relName, sub, depth-1, projects)
+		}
+	}
+	return projects
+}
+
+func hasMarker(dir string) bool {
+	for _, m := range Markers {
+		if _, err := os.Stat(filepath.Join(dir, m)); err == nil {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// makeTree creates the given paths below a temporary directory. Paths
+// ending in "/" are directories, anything else an empty file.
+func makeTree(t *testing.T, paths ...string) string {
+	t.Helper()
+	base := t.TempDir()
+	for _, p := range paths {
+		full := filepath.Join(base, p)
+		if strings.HasSuffix(p, "/") {
+			if err := os.MkdirAll(full, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return base
+}
+
+func names(projects []Project) string {
+	var n []string
+	for _, p := range projects {
+		n = append(n, p.Name)
+	}
+	return strings.Join(n, ",")
+}
+
+func TestScan_ImmediateSubdirectories(t *testing.T) {
+	base := makeTree(t, "web/", "api/", ".hidden/", "notes.txt", "org/repo/.git/")
+
+	projects, err := Scan(base, Options{})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if got := names(projects); got != "api,org,web" {
+		t.Errorf("projects = %s, want api,org,web", got)
+	}
+	if projects[0].Path != filepath.Join(base, "api") {
+		t.Errorf("Path = %q", projects[0].Path)
+	}
+}
+
+func TestScan_Recursive(t *testing.T) {
+	base := makeTree(t,
+		"api/go.mod",
+		"api/tools/inner/go.mod", // inside a project: not listed
+		"org/web/package.json",
+		"org/team/svc/Cargo.toml",
+		"org/team/deep/er/lib/pyproject.toml", // below MaxDepth
+		"org/node_modules/pkg/package.json",
+		"org/vendor/dep/go.mod",
+		"org/.cache/x/.git/",
+		"plain/",
+		"wt/.git", // worktrees and submodules have a .git file
+		"org/tools/.agent-t.yaml",
+	)
+
+	projects, err := Scan(base, Options{MaxDepth: 3})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if got, want := names(projects), "api,org/team/svc,org/tools,org/web,wt"; got != want {
+		t.Errorf("projects = %s, want %s", got, want)
+	}
+	for _, p := range projects {
+		if p.Path != filepath.Join(base, filepath.FromSlash(p.Name)) {
+			t.Errorf("%s: Path = %q", p.Name, p.Path)
+		}
+		if hasConfig := p.ConfigFile != ""; hasConfig != (p.Name == "org/tools") {
+			t.Errorf("%s: ConfigFile = %q", p.Name, p.ConfigFile)
+		}
+	}
+}
+
+func TestScan_MissingBase(t *testing.T) {
+	if _, err := Scan(filepath.Join(t.TempDir(), "nope"), Options{MaxDepth: 2}); err == nil {
+		t.Error("Scan of a missing directory should fail")
+	}
+}
+
+func TestScanRoots(t *testing.T) {
+	code := makeTree(t, "api/", "web/")
+	work := makeTree(t, "api/", "billing/")
+
+	// code is listed three times, e.g. as the working directory and a
+	// configured root, once spelled differently
+	projects, err := ScanRoots([]string{code, work, code + "/", filepath.Join(code, "api", "..")}, Options{})
+	if err != nil {
+		t.Fatalf("ScanRoots: %v", err)
+	}
+	if got := names(projects); got != "api,api,billing,web" {
+		t.Errorf("projects = %s, want api,api,billing,web", got)
+	}
+	if projects[0].Root != code || projects[1].Root != work {
+		t.Errorf("same-named projects should keep their roots in order, got %+v", projects[:2])
+	}
+
+	projects, err = ScanRoots([]string{filepath.Join(code, "missing"), work}, Options{})
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("ScanRoots error = %v, want the missing root reported", err)
+	}
+	if got := names(projects); got != "api,billing" {
+		t.Errorf("projects of the other roots = %s, want api,billing", got)
+	}
+}
@@ -0,0 +1,140 @@
+// Package trust records which repository config files the user approved
+// to run commands. A file is approved by the hash of its contents, so any
+// change to it needs approval again.
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"agent-t/internal/fileutil"
+)
+
+// Entry is an approved config file.
+type Entry struct {
+	File    string    `json:"file"`
+	Hash    string    `json:"hash"` // of the contents approved
+	Trusted time.Time `json:"trusted"`
+}
+
+// Store holds the approved files by path.
+type Store struct {
+	entries map[string]Entry
+
+	// Approvals given and withdrawn since the store was read, by file,
+	// nil for a withdrawal. Save applies them to the file as it is then.
+	changes map[string]*Entry
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{entries: make(map[string]Entry), changes: make(map[string]*Entry)}
+}
+
+// Hash identifies the contents of a config file.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Load reads the store kept at path. A missing file means an empty store.
+func Load(path string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewStore(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parse(path, data)
+}
+
+func parse(path string, data []byte) (*Store, error) {
+	s := NewStore()
+	if data == nil {
+		return s, nil
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, e := range entries {
+		s.entries[e.File] = e
+	}
+	return s, nil
+}
+
+// Save applies the approvals given and withdrawn since s was read to the
+// store kept at path, as it is now: another agent-t may have changed it
+// meanwhile, and its changes are kept. s is updated to what was written.
+func (s *Store) Save(path string) error {
+	var saved *Store
+	err := fileutil.UpdatePerm(path, 0o600, func(old []byte) ([]byte, error) {
+		disk, err := parse(path, old)
+		if err != nil {
+			return nil, err
+		}
+		for file, e := range s.changes {
+			if e == nil {
+				delete(disk.entries, file)
+			} else {
+				disk.entries[file] = *e
+			}
+		}
+		data, err := json.MarshalIndent(disk.Entries(), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		saved = disk
+		return append(data, '\n'), nil
+	})
+	if err != nil {
+		return err
+	}
+	*s = *saved
+	return nil
+}
+
+// Trusted reports whether file was approved with contents of hash.
+func (s *Store) Trusted(file, hash string) bool {
+	if s == nil {
+		return false
+	}
+	e, ok := s.entries[file]
+	return ok && e.Hash == hash
+}
+
+// Trust approves file with contents of hash, replacing an earlier
+// approval of other contents.
+func (s *Store) Trust(file, hash string, now time.Time) {
+	e := Entry{File: file, Hash: hash, Trusted: now}
+	s.entries[file] = e
+	s.changes[file] = &e
+}
+
+// Revoke withdraws the approval of file. It reports whether file was
+// approved.
+func (s *Store) Revoke(file string) bool {
+	_, ok := s.entries[file]
+	delete(s.entries, file)
+	s.changes[file] = nil
+	return ok
+}
+
+// Entries lists the approved files by path.
+func (s *Store) Entries() []Entry {
+	entries := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].File < entries[j].File
+	})
+	return entries
+}
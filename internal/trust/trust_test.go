@@ -0,0 +1,93 @@
+package trust
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "trusted.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load of a missing file: %v", err)
+	}
+
+	v1, v2 := Hash([]byte("setup: [make]")), Hash([]byte("setup: [rm -rf ~]"))
+	if s.Trusted("/r/.agent-t.yaml", v1) {
+		t.Error("nothing should be trusted in a new store")
+	}
+	s.Trust("/r/.agent-t.yaml", v1, time.Unix(0, 0).UTC())
+	s.Trust("/a/.agent-t.yaml", v1, time.Unix(0, 0).UTC())
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	s, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !s.Trusted("/r/.agent-t.yaml", v1) {
+		t.Error("approved contents should be trusted after a reload")
+	}
+	if s.Trusted("/r/.agent-t.yaml", v2) {
+		t.Error("changed contents should not be trusted")
+	}
+	if got := s.Entries(); len(got) != 2 || got[0].File != "/a/.agent-t.yaml" {
+		t.Errorf("Entries() = %+v", got)
+	}
+
+	if !s.Revoke("/r/.agent-t.yaml") || s.Revoke("/r/.agent-t.yaml") {
+		t.Error("Revoke should report whether the file was trusted")
+	}
+	if s.Trusted("/r/.agent-t.yaml", v1) {
+		t.Error("revoked file should not be trusted")
+	}
+
+	var none *Store
+	if none.Trusted("/r/.agent-t.yaml", v1) {
+		t.Error("a nil store trusts nothing")
+	}
+}
+
+func TestSave_KeepsOtherChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trusted.json")
+	v := Hash([]byte("setup: [make]"))
+	at := time.Unix(0, 0).UTC()
+	initial := NewStore()
+	initial.Trust("/a/.agent-t.yaml", v, at)
+	initial.Trust("/b/.agent-t.yaml", v, at)
+	if err := initial.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// A wizard reads the store, then `trust revoke` runs while it is open
+	wizard, _ := Load(path)
+	revoke, _ := Load(path)
+	revoke.Revoke("/a/.agent-t.yaml")
+	if err := revoke.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	wizard.Trust("/c/.agent-t.yaml", v, at)
+	if err := wizard.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Trusted("/a/.agent-t.yaml", v) {
+		t.Error("the revocation made meanwhile should not be undone")
+	}
+	if !s.Trusted("/b/.agent-t.yaml", v) || !s.Trusted("/c/.agent-t.yaml", v) {
+		t.Errorf("entries = %+v, want b kept and c added", s.Entries())
+	}
+	if !wizard.Trusted("/b/.agent-t.yaml", v) || wizard.Trusted("/a/.agent-t.yaml", v) {
+		t.Error("Save should update the store to what was written")
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("store file mode = %v, %v; want 0600", fi.Mode().Perm(), err)
+	}
+}
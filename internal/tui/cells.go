@@ -0,0 +1,499 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"agent-t/internal/config"
+	"agent-t/internal/launcher"
+	"agent-t/internal/scanner"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Cell is what one terminal of the grid runs once its row's selections and
+// any per-cell overrides are resolved.
+type Cell struct {
+	Project scanner.Project
+	Tool    Tool
+	Title   string
+	Env     map[string]string
+	Setup   []string // the project's setup commands
+}
+
+// cellOverride replaces parts of a cell's row assignment. Nil fields follow
+// the row.
+type cellOverride struct {
+	project *scanner.Project
+	tool    *Tool
+	title   string
+	env     map[string]string
+}
+
+func (o cellOverride) isZero() bool {
+	return o.project == nil && o.tool == nil && o.title == "" && len(o.env) == 0
+}
+
+// Cells resolves every cell of the selected layout in row-major order. In
+// split mode each row (or column) uses its own assignment; single mode uses
+// the same selections everywhere. The defaults of the project's config file
+// apply on top of the assignment, and per-cell overrides on top of both.
+func (m Model) Cells() []Cell {
+	var cells []Cell
+	inGroup := make(map[int]int) // cells so far in each group
+	for r, cols := range m.selectedLayout.RowCols {
+		for c := 0; c < cols; c++ {
+			group := 0
+			if m.splitMode {
+				group = m.selectedLayout.GroupOf(r, c)
+			}
+			cells = append(cells, m.cellAt(len(cells), group, inGroup[group]))
+			inGroup[group]++
+		}
+	}
+	return cells
+}
+
+// cellAt resolves cell i, the n-th cell of its group. Single mode has one
+// group.
+func (m Model) cellAt(i, group, n int) Cell {
+	cell := Cell{Project: m.selectedProject, Tool: m.selectedTool}
+	if m.splitMode && len(m.rows) > 0 {
+		row := m.rows[min(group, len(m.rows)-1)]
+		cell = Cell{Project: row.Project, Tool: row.Tool}
+	}
+	var o cellOverride
+	if i < len(m.cells) {
+		o = m.cells[i]
+	}
+	if o.project != nil {
+		cell.Project = *o.project
+	}
+	m.applyProjectCell(&cell, cell.Project, n)
+	if o.tool != nil {
+		cell.Tool = *o.tool
+	}
+	if o.title != "" {
+		cell.Title = o.title
+	}
+	if len(o.env) > 0 {
+		cell.Env = merge(cell.Env, o.env)
+	}
+	return cell
+}
+
+// customizedCells counts the cells of the selected layout with an override.
+func (m Model) customizedCells() int {
+	n := 0
+	for i := 0; i < len(m.cells) && i < m.selectedLayout.TotalTerminals(); i++ {
+		if !m.cells[i].isZero() {
+			n++
+		}
+	}
+	return n
+}
+
+// override returns a pointer to cell i's override, growing the list as needed.
+func (m *Model) override(i int) *cellOverride {
+	if i >= len(m.cells) {
+		m.cells = append(m.cells, make([]cellOverride, i+1-len(m.cells))...)
+	}
+	return &m.cells[i]
+}
+
+// applyPresetCells resolves the per-cell overrides of a preset.
+func (m *Model) applyPresetCells(cells []config.PresetCell) []string {
+	var errs []string
+	m.cells = nil
+	for i, pc := range cells {
+		o := cellOverride{title: pc.Title, env: pc.Env}
+		// Tools are looked up among those offered for the cell's project
+		proj := m.selectedProject
+		if i < m.selectedLayout.TotalTerminals() {
+			proj = m.rowCell(i).Project
+		}
+		if pc.Project != "" {
+			p, err := m.findProject(pc.Project)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("cell %d: %v", i+1, err))
+			} else {
+				o.project = &p
+				proj = p
+			}
+		}
+		if pc.Command != "" {
+			o.tool = &Tool{Command: pc.Command}
+		} else if pc.Tool != "" {
+			tool, err := m.findTool(proj, pc.Tool)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("cell %d: %v", i+1, err))
+			} else {
+				o.tool = &tool
+			}
+		}
+		m.cells = append(m.cells, o)
+	}
+	return errs
+}
+
+// presetCells converts the overrides back to preset cells, trimming the
+// trailing cells that follow their row.
+func (m Model) presetCells() []config.PresetCell {
+	n := min(len(m.cells), m.selectedLayout.TotalTerminals())
+	for n > 0 && m.cells[n-1].isZero() {
+		n--
+	}
+	if n == 0 {
+		return nil
+	}
+	cells := make([]config.PresetCell, n)
+	for i, o := range m.cells[:n] {
+		pc := config.PresetCell{Title: o.title, Env: o.env}
+		if o.project != nil {
+			pc.Project = m.presetProject(*o.project)
+		}
+		if o.tool != nil {
+			if o.tool.Name == "" {
+				pc.Command = o.tool.Command
+			} else {
+				pc.Tool = o.tool.Name
+			}
+		}
+		cells[i] = pc
+	}
+	return cells
+}
+
+// --- Cell customization steps ---
+
+// cellKeys are the extra keys on the cell list.
+var cellKeys = struct {
+	Title, Env, Reset key.Binding
+}{
+	Title: key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "title")),
+	Env:   key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "env")),
+	Reset: key.NewBinding(key.WithKeys("x", "delete"), key.WithHelp("x", "reset")),
+}
+
+type cellItem struct {
+	index      int
+	row, col   int
+	cell       Cell
+	customized bool
+	done       bool
+}
+
+func (i cellItem) Title() string {
+	if i.done {
+		return "Done"
+	}
+	title := fmt.Sprintf("Cell %d,%d", i.row+1, i.col+1)
+	if i.cell.Title != "" {
+		title += " " + i.cell.Title
+	}
+	if i.customized {
+		title += " (custom)"
+	}
+	return title
+}
+
+func (i cellItem) Description() string {
+	if i.done {
+		return "Back to confirm"
+	}
+	desc := i.cell.Project.Name + " | " + i.cell.Tool.displayName()
+	if len(i.cell.Env) > 0 {
+		desc += " | env " + strings.Join(sortedKeys(i.cell.Env), ",")
+	}
+	return desc
+}
+
+func (i cellItem) FilterValue() string { return i.Title() }
+
+// displayName names a tool for display; raw commands from a preset have
+// no name.
+func (t Tool) displayName() string {
+	if t.Name == "" {
+		return t.Command
+	}
+	return t.Name
+}
+
+func (m Model) newCellList(selected int) list.Model {
+	items := []list.Item{cellItem{done: true}}
+	cells := m.Cells()
+	i := 0
+	for r, cols := range m.selectedLayout.RowCols {
+		for c := 0; c < cols; c++ {
+			customized := i < len(m.cells) && !m.cells[i].isZero()
+			items = append(items, cellItem{index: i, row: r, col: c, cell: cells[i], customized: customized})
+			i++
+		}
+	}
+	w, h := m.listSize()
+	l := list.New(items, newStyledDelegate(), w, h)
+	l.Title = "Cells"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(true)
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{cellKeys.Title, cellKeys.Env, cellKeys.Reset}
+	}
+	l.Select(selected + 1) // index 0 is "Done"
+	return l
+}
+
+// startCells opens the cell list with the cursor on cell selected.
+func (m Model) startCells(selected int) Model {
+	m.currentStep = stepCells
+	m.list = m.newCellList(selected)
+	return m
+}
+
+// updateCellKeys handles the extra keys on the cell list. ok is false when
+// msg is not one of them and should go to the list instead.
+func (m Model) updateCellKeys(msg tea.KeyMsg) (model tea.Model, cmd tea.Cmd, ok bool) {
+	selected := m.list.SelectedItem()
+	if selected == nil {
+		return m, nil, false
+	}
+	item := selected.(cellItem)
+	if item.done {
+		return m, nil, false
+	}
+
+	switch {
+	case key.Matches(msg, cellKeys.Reset):
+		if item.index < len(m.cells) {
+			m.cells[item.index] = cellOverride{}
+		}
+		return m.startCells(item.index), nil, true
+
+	case key.Matches(msg, cellKeys.Title):
+		m.editingCell = item.index
+		m.enteringCellTitle = true
+		m.presetInput.SetValue(item.cell.Title)
+		m.presetInput.CursorEnd()
+		return m, m.presetInput.Focus(), true
+
+	case key.Matches(msg, cellKeys.Env):
+		m.editingCell = item.index
+		m.enteringCellEnv = true
+		var env map[string]string
+		if item.index < len(m.cells) {
+			env = m.cells[item.index].env
+		}
+		m.presetInput.SetValue(formatEnv(env))
+		m.presetInput.CursorEnd()
+		return m, m.presetInput.Focus(), true
+	}
+	return m, nil, false
+}
+
+func (m Model) updateCellTitleInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.override(m.editingCell).title = strings.TrimSpace(m.presetInput.Value())
+		fallthrough
+	case "esc":
+		m.enteringCellTitle = false
+		m.presetInput.Reset()
+		m.presetInput.Blur()
+		return m.startCells(m.editingCell), nil
+	}
+
+	var cmd tea.Cmd
+	m.presetInput, cmd = m.presetInput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) cellTitleView() string {
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(promptStyle.Render("Cell title: "))
+	b.WriteString(m.presetInput.View())
+	b.WriteString("\n\n")
+	b.WriteString(dimStyle.Render("Enter to save (empty for the default) • Esc to cancel"))
+	return b.String()
+}
+
+func (m Model) updateCellEnvInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		env, err := parseEnv(m.presetInput.Value())
+		if err != nil {
+			m.statusMsg = err.Error()
+			return m, nil
+		}
+		m.override(m.editingCell).env = env
+		fallthrough
+	case "esc":
+		m.enteringCellEnv = false
+		m.presetInput.Reset()
+		m.presetInput.Blur()
+		return m.startCells(m.editingCell), nil
+	}
+
+	var cmd tea.Cmd
+	m.presetInput, cmd = m.presetInput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) cellEnvView() string {
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(promptStyle.Render("Cell env: "))
+	b.WriteString(m.presetInput.View())
+	b.WriteString("\n\n")
+	b.WriteString(dimStyle.Render("NAME=value pairs separated by spaces, values quoted as in a shell • Enter to save (empty for none) • Esc to cancel"))
+	return b.String()
+}
+
+// parseEnv reads the NAME=value pairs of the cell env input. Pairs are
+// separated by spaces; a value that contains spaces is quoted as in a
+// shell, with single or double quotes, or by escaping them with \.
+func parseEnv(s string) (map[string]string, error) {
+	words, err := splitWords(s)
+	if err != nil {
+		return nil, err
+	}
+	var env map[string]string
+	for _, pair := range words {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not NAME=value", pair)
+		}
+		if !launcher.ValidEnvName(name) {
+			return nil, fmt.Errorf("env name %q is not a valid variable name", name)
+		}
+		if env == nil {
+			env = make(map[string]string)
+		}
+		env[name] = value
+	}
+	return env, nil
+}
+
+// splitWords splits s at unquoted spaces and removes the quotes and
+// backslashes, as a shell does. Inside double quotes, \ only escapes " and
+// \; inside single quotes, nothing is escaped.
+func splitWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		word.WriteRune('\\')
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// formatEnv is env as the cell env input shows it, with values that
+// parseEnv would split or unquote in double quotes.
+func formatEnv(env map[string]string) string {
+	pairs := make([]string, 0, len(env))
+	for _, name := range sortedKeys(env) {
+		value := env[name]
+		if strings.ContainsAny(value, " \t\"'\\") {
+			value = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+		}
+		pairs = append(pairs, name+"="+value)
+	}
+	return strings.Join(pairs, " ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// selectCellProject overrides the edited cell's project, or clears the
+// override when p is what the row already uses.
+func (m *Model) selectCellProject(p scanner.Project) {
+	o := m.override(m.editingCell)
+	o.project = nil
+	if m.rowCell(m.editingCell).Project.Path != p.Path {
+		o.project = &p
+	}
+}
+
+// selectCellTool overrides the edited cell's tool, or clears the override
+// when t is what the row already uses.
+func (m *Model) selectCellTool(t Tool) {
+	o := m.override(m.editingCell)
+	o.tool = nil
+	if m.rowCell(m.editingCell).Tool != t {
+		o.tool = &t
+	}
+}
+
+// rowCell is cell i as its row (or column) and its project's config file
+// assign it, ignoring overrides.
+func (m Model) rowCell(i int) Cell {
+	m.cells = nil
+	return m.Cells()[i]
+}
+
+// cellProjectList builds the project list with the cursor on the edited
+// cell's project.
+func (m Model) cellProjectList() list.Model {
+	return m.projectList(m.Cells()[m.editingCell].Project.Path)
+}
+
+// cellToolList builds the tool list with the cursor on the edited cell's tool.
+func (m Model) cellToolList() list.Model {
+	w, h := m.listSize()
+	cell := m.Cells()[m.editingCell]
+	return newToolList(m.toolsFor(cell.Project), w, h, cell.Tool.Name)
+}
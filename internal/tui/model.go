@@ -1,12 +1,18 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"agent-t/internal/config"
+	"agent-t/internal/history"
 	"agent-t/internal/scanner"
+	"agent-t/internal/trust"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -25,8 +31,15 @@ type Model struct {
 	projects []scanner.Project
 	cfg      *config.Config
 	cwd      string
-	tools    []Tool
-	layouts  []Layout
+
+	// Repository config files by project path, read on first use
+	projectCfgs map[string]projectConfig
+
+	// Approval of repository config files that run commands
+	trust        *trust.Store
+	trustChanged bool
+	ignoredCfgs  map[string]bool  // config files declined this session
+	trusting     *scanner.Project // project whose config file awaits approval
 
 	list list.Model
 
@@ -35,10 +48,28 @@ type Model struct {
 	selectedLayout  Layout
 	selectedTool    Tool
 
-	// Split workspace mode
-	splitMode             bool
-	selectedBottomProject scanner.Project
-	selectedToolBottom    Tool
+	// The most recent launch, offered at the top of the preset list
+	last *history.Entry
+
+	// Project list order: frecency scores by project path, unless toggled
+	// to alphabetical
+	frecency   map[string]float64
+	sortByName bool
+
+	// Split workspace mode: one project and tool per row, or per column
+	// of a single-row layout
+	splitMode bool
+	rows      []Row
+	row       int // row being assigned
+
+	// Give each cell running a tool its own git worktree
+	worktrees bool
+
+	// Per-cell overrides in row-major order
+	cells             []cellOverride
+	editingCell       int // cell being customized
+	enteringCellTitle bool
+	enteringCellEnv   bool
 
 	// Preset naming
 	namingPreset bool
@@ -47,30 +78,47 @@ type Model struct {
 	// Custom layout input
 	enteringCustomLayout bool
 	customLayoutInput    textinput.Model
-}
 
-func NewModel(projects []scanner.Project, cfg *config.Config, cwd string) Model {
-	tools := AllTools(cfg)
-	layouts := AllLayouts(cfg)
+	// Preset management on the preset list
+	deletingPreset string // preset awaiting delete confirmation
+	renamingPreset string // preset being renamed through presetInput
+	editingPreset  string // preset being edited through the wizard
 
+	// Error shown under the step title, cleared on the next key press
+	statusMsg string
+
+	// Problems in the config file, shown under the header
+	warnings []config.Problem
+}
+
+func NewModel(projects []scanner.Project, cfg *config.Config, cwd string, launches []history.Entry, trusted *trust.Store) Model {
+	if trusted == nil {
+		trusted = trust.NewStore()
+	}
 	m := Model{
-		projects: projects,
-		cfg:      cfg,
-		cwd:      cwd,
-		tools:    tools,
-		layouts:  layouts,
+		projects:    projects,
+		cfg:         cfg,
+		cwd:         cwd,
+		projectCfgs: make(map[string]projectConfig),
+		trust:       trusted,
+		ignoredCfgs: make(map[string]bool),
+		frecency:    history.Frecency(launches, time.Now()),
+		warnings:    CheckConfig(cfg, projects),
+	}
+	if len(launches) > 0 {
+		m.last = &launches[len(launches)-1]
 	}
 
-	// Start on presets if any exist, otherwise mode selection (if >= 2 projects) or project
-	if len(cfg.Presets) > 0 {
+	// Start on presets if any exist or a workspace was launched before, otherwise mode selection (if >= 2 projects) or project
+	if m.hasPresetList() {
 		m.currentStep = stepPreset
-		m.list = newPresetList(cfg.Presets, 60, 20)
+		m.list = newPresetList(cfg, m.lastItem(), 60, 20)
 	} else if len(projects) >= 2 {
 		m.currentStep = stepMode
 		m.list = newModeList(60, 20)
 	} else {
 		m.currentStep = stepProject
-		m.list = newProjectList(projects, 60, 20)
+		m.list = m.projectList("")
 	}
 
 	// Prepare text input for preset naming
@@ -90,8 +138,10 @@ func NewModel(projects []scanner.Project, cfg *config.Config, cwd string) Model
 	return m
 }
 
+// Init starts reading the git state and languages of the projects, which
+// fill in the project list as they arrive.
 func (m Model) Init() tea.Cmd {
-	return nil
+	return m.inspectProjects()
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -101,7 +151,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		h, v := appStyle.GetFrameSize()
 		listW := msg.Width - h
-		overhead := 6 + m.selectionLineCount()
+		overhead := 6 + m.selectionLineCount() + m.warningLineCount()
 		listH := msg.Height - v - overhead
 		if listH < 5 {
 			listH = 5
@@ -109,15 +159,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.list.SetSize(listW, listH)
 		return m, nil
 
+	case projectsInspectedMsg:
+		return m, m.setProjectInfo(msg)
+
 	case tea.KeyMsg:
+		m.statusMsg = ""
 		// Handle preset naming mode separately
 		if m.namingPreset {
 			return m.updatePresetNaming(msg)
 		}
+		if m.deletingPreset != "" {
+			return m.updateDeleteConfirm(msg)
+		}
+		if m.trusting != nil {
+			return m.updateTrustPrompt(msg)
+		}
 		// Handle custom layout input mode
 		if m.enteringCustomLayout {
 			return m.updateCustomLayoutInput(msg)
 		}
+		if m.enteringCellTitle {
+			return m.updateCellTitleInput(msg)
+		}
+		if m.enteringCellEnv {
+			return m.updateCellEnvInput(msg)
+		}
 
 		// Don't intercept keys when the list is filtering
 		if m.list.FilterState() == list.Filtering {
@@ -135,6 +201,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			return m.advance()
 		}
+
+		if m.currentStep == stepPreset {
+			if model, cmd, ok := m.updatePresetKeys(msg); ok {
+				return model, cmd
+			}
+		}
+		if m.currentStep == stepCells {
+			if model, cmd, ok := m.updateCellKeys(msg); ok {
+				return model, cmd
+			}
+		}
+		if m.currentStep == stepProject || m.currentStep == stepCellProject {
+			if model, cmd, ok := m.updateProjectKeys(msg); ok {
+				return model, cmd
+			}
+		}
 	}
 
 	// Delegate to the list
@@ -154,17 +236,23 @@ func (m Model) View() string {
 	header := headerStyle.Render(titleStyle.Render("Agent T") + "  Workspace Launcher")
 	b.WriteString(header)
 	b.WriteString("\n")
+	b.WriteString(m.warningsView())
 
 	// Step indicator
 	if m.currentStep != stepPreset {
-		num, total := stepNumber(m.currentStep, len(m.cfg.Presets) > 0, m.splitMode)
-		b.WriteString(stepStyle.Render(fmt.Sprintf("Step %d/%d: %s", num, total, stepTitle(m.currentStep, m.splitMode))))
+		num, total := stepNumber(m.currentStep, m.hasPresetList(), m.splitGroups(), m.row)
+		b.WriteString(stepStyle.Render(fmt.Sprintf("Step %d/%d: %s", num, total, stepTitle(m.currentStep, m.groupName()))))
 		b.WriteString("\n")
 	} else {
-		b.WriteString(stepStyle.Render(stepTitle(m.currentStep, m.splitMode)))
+		b.WriteString(stepStyle.Render(stepTitle(m.currentStep, "")))
 		b.WriteString("\n")
 	}
 
+	if m.statusMsg != "" {
+		b.WriteString(errorStyle.Render(m.statusMsg))
+		b.WriteString("\n\n")
+	}
+
 	// Previous selections summary
 	b.WriteString(m.selectionSummary())
 
@@ -174,12 +262,36 @@ func (m Model) View() string {
 		return appStyle.Render(b.String())
 	}
 
+	// Delete confirmation overlay
+	if m.deletingPreset != "" {
+		b.WriteString(m.deleteConfirmView())
+		return appStyle.Render(b.String())
+	}
+
+	// Repository config approval overlay
+	if m.trusting != nil {
+		b.WriteString(m.trustView())
+		return appStyle.Render(b.String())
+	}
+
 	// Custom layout input overlay
 	if m.enteringCustomLayout {
 		b.WriteString(m.customLayoutView())
 		return appStyle.Render(b.String())
 	}
 
+	// Cell title input overlay
+	if m.enteringCellTitle {
+		b.WriteString(m.cellTitleView())
+		return appStyle.Render(b.String())
+	}
+
+	// Cell env input overlay
+	if m.enteringCellEnv {
+		b.WriteString(m.cellEnvView())
+		return appStyle.Render(b.String())
+	}
+
 	// Confirm step has a special view
 	if m.currentStep == stepConfirm {
 		b.WriteString(m.confirmView())
@@ -192,33 +304,42 @@ func (m Model) View() string {
 	return appStyle.Render(b.String())
 }
 
+// maxWarnings is how many config problems the header lists.
+const maxWarnings = 3
+
+func (m Model) warningsView() string {
+	if len(m.warnings) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for i, w := range m.warnings {
+		if i == maxWarnings {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  ...and %d more", len(m.warnings)-maxWarnings)))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString(warningStyle.Render("! " + w.String()))
+		b.WriteString("\n")
+	}
+	b.WriteString(dimStyle.Render("  run `agent-t config check` for details"))
+	b.WriteString("\n\n")
+	return b.String()
+}
+
+func (m Model) warningLineCount() int {
+	if len(m.warnings) == 0 {
+		return 0
+	}
+	return min(len(m.warnings), maxWarnings+1) + 2
+}
+
 func (m Model) selectionSummary() string {
 	var b strings.Builder
 
 	if m.splitMode {
-		if m.currentStep > stepProject {
-			b.WriteString(selectionLabelStyle.Render("Top:"))
-			b.WriteString(selectionValueStyle.Render(m.selectedProject.Name))
-			b.WriteString("\n")
-		}
-		if m.currentStep > stepProjectBottom {
-			b.WriteString(selectionLabelStyle.Render("Bottom:"))
-			b.WriteString(selectionValueStyle.Render(m.selectedBottomProject.Name))
-			b.WriteString("\n")
-		}
-		if m.currentStep > stepLayout {
-			b.WriteString(selectionLabelStyle.Render("Layout:"))
-			b.WriteString(selectionValueStyle.Render(fmt.Sprintf("%s (%s)", m.selectedLayout.Name, m.selectedLayout.Desc)))
-			b.WriteString("\n")
-		}
-		if m.currentStep > stepTool {
-			b.WriteString(selectionLabelStyle.Render("Top Tool:"))
-			b.WriteString(selectionValueStyle.Render(m.selectedTool.Name))
-			b.WriteString("\n")
-		}
-		if m.currentStep > stepToolBottom {
-			b.WriteString(selectionLabelStyle.Render("Btm Tool:"))
-			b.WriteString(selectionValueStyle.Render(m.selectedToolBottom.Name))
+		for _, sel := range m.splitSelections() {
+			b.WriteString(selectionLabelStyle.Render(sel[0]))
+			b.WriteString(selectionValueStyle.Render(sel[1]))
 			b.WriteString("\n")
 		}
 	} else {
@@ -245,21 +366,79 @@ func (m Model) selectionSummary() string {
 	return b.String()
 }
 
+// splitSelections lists the label and value of what has been picked so far
+// in split mode: the layout, then one line per assigned row.
+func (m Model) splitSelections() [][2]string {
+	var sels [][2]string
+	if m.currentStep == stepMode || m.currentStep == stepLayout {
+		return sels
+	}
+	sels = append(sels, [2]string{"Layout:", fmt.Sprintf("%s (%s)", m.selectedLayout.Name, m.selectedLayout.Desc)})
+	done := len(m.rows)
+	if m.currentStep == stepProject || m.currentStep == stepTool {
+		done = m.row
+	}
+	for i := 0; i < done; i++ {
+		sels = append(sels, [2]string{m.selectedLayout.GroupName(i) + ":", fmt.Sprintf("%s (%s)", m.rows[i].Project.Name, m.rows[i].Tool.Name)})
+	}
+	if m.currentStep == stepTool {
+		sels = append(sels, [2]string{m.selectedLayout.GroupName(m.row) + ":", m.rows[m.row].Project.Name})
+	}
+	return sels
+}
+
+// splitGroups is how many rows (or columns) the split workspace assigns,
+// or 0 in single mode. Before a layout is picked it assumes two.
+func (m Model) splitGroups() int {
+	if !m.splitMode {
+		return 0
+	}
+	return max(m.selectedLayout.Groups(), 2)
+}
+
+// groupName names the row or column being assigned, e.g. "Row 2".
+func (m Model) groupName() string {
+	if m.splitMode && (m.currentStep == stepProject || m.currentStep == stepTool) {
+		return m.selectedLayout.GroupName(m.row)
+	}
+	return ""
+}
+
+// setGroups sizes the row assignments to the selected layout, keeping the
+// ones already made.
+func (m *Model) setGroups() {
+	n := m.selectedLayout.Groups()
+	if len(m.rows) > n {
+		m.rows = m.rows[:n]
+	}
+	for len(m.rows) < n {
+		m.rows = append(m.rows, Row{})
+	}
+	m.row = 0
+}
+
+// layoutList builds the layout list for the current mode.
+func (m Model) layoutList() list.Model {
+	w, h := m.listSize()
+	if m.splitMode {
+		return newLayoutList(AllSplitLayouts(m.cfg), w, h, m.layoutDefault())
+	}
+	return newLayoutList(AllLayouts(m.cfgFor(m.selectedProject)), w, h, m.layoutDefault())
+}
+
 func (m Model) confirmView() string {
 	var b strings.Builder
 
 	// Summary box
 	var summary string
 	if m.splitMode {
-		summary = lipgloss.JoinVertical(lipgloss.Left,
-			confirmLabelStyle.Render("Top Project:")+confirmValueStyle.Render(m.selectedProject.Name),
-			confirmLabelStyle.Render("Top Dir:")+confirmValueStyle.Render(m.selectedProject.Path),
-			confirmLabelStyle.Render("Top Tool:")+confirmValueStyle.Render(m.selectedTool.Name),
-			confirmLabelStyle.Render("Btm Project:")+confirmValueStyle.Render(m.selectedBottomProject.Name),
-			confirmLabelStyle.Render("Btm Dir:")+confirmValueStyle.Render(m.selectedBottomProject.Path),
-			confirmLabelStyle.Render("Btm Tool:")+confirmValueStyle.Render(m.selectedToolBottom.Name),
-			confirmLabelStyle.Render("Layout:")+confirmValueStyle.Render(fmt.Sprintf("%s (%s)", m.selectedLayout.Name, m.selectedLayout.Desc)),
-		)
+		var lines []string
+		for i, r := range m.rows {
+			lines = append(lines, confirmLabelStyle.Render(m.selectedLayout.GroupName(i)+":")+
+				confirmValueStyle.Render(fmt.Sprintf("%s (%s) %s", r.Project.Name, r.Tool.Name, r.Project.Path)))
+		}
+		lines = append(lines, confirmLabelStyle.Render("Layout:")+confirmValueStyle.Render(fmt.Sprintf("%s (%s)", m.selectedLayout.Name, m.selectedLayout.Desc)))
+		summary = lipgloss.JoinVertical(lipgloss.Left, lines...)
 	} else {
 		summary = lipgloss.JoinVertical(lipgloss.Left,
 			confirmLabelStyle.Render("Project:")+confirmValueStyle.Render(m.selectedProject.Name),
@@ -268,6 +447,16 @@ func (m Model) confirmView() string {
 			confirmLabelStyle.Render("Directory:")+confirmValueStyle.Render(m.selectedProject.Path),
 		)
 	}
+	if m.worktrees {
+		summary = lipgloss.JoinVertical(lipgloss.Left, summary,
+			confirmLabelStyle.Render("Worktrees:")+confirmValueStyle.Render("one per cell running a tool"),
+		)
+	}
+	if n := m.customizedCells(); n > 0 {
+		summary = lipgloss.JoinVertical(lipgloss.Left, summary,
+			confirmLabelStyle.Render("Cells:")+confirmValueStyle.Render(fmt.Sprintf("%d customized", n)),
+		)
+	}
 	b.WriteString(confirmBoxStyle.Render(summary))
 	b.WriteString("\n\n")
 
@@ -280,7 +469,11 @@ func (m Model) confirmView() string {
 func (m Model) presetNamingView() string {
 	var b strings.Builder
 	b.WriteString("\n")
-	b.WriteString(promptStyle.Render("Preset name: "))
+	if m.renamingPreset != "" {
+		b.WriteString(promptStyle.Render(fmt.Sprintf("Rename %q to: ", m.renamingPreset)))
+	} else {
+		b.WriteString(promptStyle.Render("Preset name: "))
+	}
 	b.WriteString(m.presetInput.View())
 	b.WriteString("\n\n")
 	b.WriteString(dimStyle.Render("Enter to save • Esc to cancel"))
@@ -296,6 +489,9 @@ func (m Model) advance() (tea.Model, tea.Cmd) {
 		}
 		item := selected.(presetItem)
 		if item.isNew {
+			m.worktrees = false
+			m.rows = nil
+			m.cells = nil
 			// Go to mode selection if >= 2 projects, else straight to project
 			if len(m.projects) >= 2 {
 				m.currentStep = stepMode
@@ -303,13 +499,22 @@ func (m Model) advance() (tea.Model, tea.Cmd) {
 				m.list = newModeList(w, h)
 			} else {
 				m.currentStep = stepProject
-				w, h := m.listSize()
-				m.list = newProjectList(m.projects, w, h)
+				m.list = m.projectListFor(m.selectedProject)
 			}
 		} else {
 			// Apply preset and launch
+			if err := m.applyPreset(item.preset); err != nil {
+				if p, ok := m.untrustedCell(); ok {
+					return m.askTrust(p)
+				}
+				if item.last {
+					m.statusMsg = fmt.Sprintf("Last workspace: %v", err)
+				} else {
+					m.statusMsg = fmt.Sprintf("Preset %q: %v", item.preset.Name, err)
+				}
+				return m, nil
+			}
 			m.selectedPreset = &item.preset
-			m.applyPreset(item.preset)
 			m.currentStep = stepDone
 			return m, tea.Quit
 		}
@@ -320,35 +525,33 @@ func (m Model) advance() (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.splitMode = selected.(modeItem).split
-		m.currentStep = stepProject
-		w, h := m.listSize()
-		m.list = newProjectList(m.projects, w, h)
+		if m.splitMode {
+			// Split workspaces pick the layout first, then loop over its rows
+			m.currentStep = stepLayout
+			m.list = m.layoutList()
+		} else {
+			m.currentStep = stepProject
+			m.list = m.projectListFor(m.selectedProject)
+		}
 
 	case stepProject:
 		selected := m.list.SelectedItem()
 		if selected == nil {
 			return m, nil
 		}
-		m.selectedProject = selected.(projectItem).project
+		if p := selected.(projectItem).project; m.needsTrust(p) {
+			return m.askTrust(p)
+		}
+		m.statusMsg = m.projectConfigError(selected.(projectItem).project)
 		if m.splitMode {
-			m.currentStep = stepProjectBottom
-			w, h := m.listSize()
-			m.list = newProjectList(m.projects, w, h)
+			m.rows[m.row].Project = selected.(projectItem).project
+			m.currentStep = stepTool
+			m.list = m.toolList(m.rows[m.row].Project, m.rows[m.row].Tool)
 		} else {
+			m.selectedProject = selected.(projectItem).project
 			m.currentStep = stepLayout
-			w, h := m.listSize()
-			m.list = newLayoutList(m.layouts, w, h, m.cfg.DefaultLayout)
-		}
-
-	case stepProjectBottom:
-		selected := m.list.SelectedItem()
-		if selected == nil {
-			return m, nil
+			m.list = m.layoutList()
 		}
-		m.selectedBottomProject = selected.(projectItem).project
-		m.currentStep = stepLayout
-		w, h := m.listSize()
-		m.list = newSplitLayoutList(w, h, m.cfg.DefaultLayout)
 
 	case stepLayout:
 		selected := m.list.SelectedItem()
@@ -361,44 +564,57 @@ func (m Model) advance() (tea.Model, tea.Cmd) {
 			cmd := m.customLayoutInput.Focus()
 			return m, cmd
 		}
-		m.selectedLayout = lay
-		m.currentStep = stepTool
-		w, h := m.listSize()
-		m.list = newToolList(m.tools, w, h, m.cfg.DefaultTool)
+		m.setLayout(lay)
+		return m.afterLayout(), nil
 
 	case stepTool:
 		selected := m.list.SelectedItem()
 		if selected == nil {
 			return m, nil
 		}
-		m.selectedTool = selected.(toolItem).tool
 		if m.splitMode {
-			m.currentStep = stepToolBottom
-			w, h := m.listSize()
-			m.list = newToolList(m.tools, w, h, m.cfg.DefaultTool)
+			m.rows[m.row].Tool = selected.(toolItem).tool
+			if m.row+1 < len(m.rows) {
+				m.row++
+				m.currentStep = stepProject
+				m.list = m.projectListFor(m.rows[m.row].Project)
+				return m, nil
+			}
 		} else {
-			m.currentStep = stepConfirm
-			w, h := m.listSize()
-			m.list = newConfirmList(w, h)
-		}
-
-	case stepToolBottom:
-		selected := m.list.SelectedItem()
-		if selected == nil {
-			return m, nil
+			m.selectedTool = selected.(toolItem).tool
 		}
-		m.selectedToolBottom = selected.(toolItem).tool
 		m.currentStep = stepConfirm
 		w, h := m.listSize()
-		m.list = newConfirmList(w, h)
+		m.list = newConfirmList(w, h, m.editingPreset, m.worktrees)
 
 	case stepConfirm:
 		selected := m.list.SelectedItem()
 		if selected == nil {
 			return m, nil
 		}
+		if _, ok := selected.(worktreeItem); ok {
+			m.worktrees = !m.worktrees
+			idx := m.list.Index()
+			w, h := m.listSize()
+			m.list = newConfirmList(w, h, m.editingPreset, m.worktrees)
+			m.list.Select(idx)
+			return m, nil
+		}
 		item := selected.(confirmItem)
-		if item.name == "Launch" {
+		switch item.name {
+		case customizeCellsItem.name:
+			return m.startCells(0), nil
+		case "Launch":
+			m.currentStep = stepDone
+			return m, tea.Quit
+		case "Update preset & Launch", "Update preset":
+			if err := m.updateEditedPreset(); err != nil {
+				m.statusMsg = err.Error()
+				return m, nil
+			}
+			if item.name == "Update preset" {
+				return m.backToPresets(), nil
+			}
 			m.currentStep = stepDone
 			return m, tea.Quit
 		}
@@ -406,11 +622,71 @@ func (m Model) advance() (tea.Model, tea.Cmd) {
 		m.namingPreset = true
 		cmd := m.presetInput.Focus()
 		return m, cmd
+
+	case stepCells:
+		selected := m.list.SelectedItem()
+		if selected == nil {
+			return m, nil
+		}
+		item := selected.(cellItem)
+		if item.done {
+			m.currentStep = stepConfirm
+			w, h := m.listSize()
+			m.list = newConfirmList(w, h, m.editingPreset, m.worktrees)
+			return m, nil
+		}
+		m.editingCell = item.index
+		m.currentStep = stepCellProject
+		m.list = m.cellProjectList()
+
+	case stepCellProject:
+		selected := m.list.SelectedItem()
+		if selected == nil {
+			return m, nil
+		}
+		if p := selected.(projectItem).project; m.needsTrust(p) {
+			return m.askTrust(p)
+		}
+		m.selectCellProject(selected.(projectItem).project)
+		m.currentStep = stepCellTool
+		m.list = m.cellToolList()
+
+	case stepCellTool:
+		selected := m.list.SelectedItem()
+		if selected == nil {
+			return m, nil
+		}
+		m.selectCellTool(selected.(toolItem).tool)
+		return m.startCells(m.editingCell), nil
 	}
 
 	return m, nil
 }
 
+// setLayout selects lay. Cell overrides are kept only while the layout
+// stays the same: they are by position, and another grid puts different
+// cells there.
+func (m *Model) setLayout(lay Layout) {
+	if lay.ID() != m.selectedLayout.ID() {
+		m.cells = nil
+	}
+	m.selectedLayout = lay
+}
+
+// afterLayout moves on once a layout is picked: to the first row's project
+// in split mode, otherwise to the tool.
+func (m Model) afterLayout() Model {
+	if m.splitMode {
+		m.setGroups()
+		m.currentStep = stepProject
+		m.list = m.projectListFor(m.rows[0].Project)
+		return m
+	}
+	m.currentStep = stepTool
+	m.list = m.toolList(m.selectedProject, m.selectedTool)
+	return m
+}
+
 func (m Model) goBack() (tea.Model, tea.Cmd) {
 	switch m.currentStep {
 	case stepPreset:
@@ -418,69 +694,73 @@ func (m Model) goBack() (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 
 	case stepMode:
-		if len(m.cfg.Presets) > 0 {
-			m.currentStep = stepPreset
-			w, h := m.listSize()
-			m.list = newPresetList(m.cfg.Presets, w, h)
+		if m.hasPresetList() {
+			return m.backToPresets(), nil
 		} else {
 			m.cancelled = true
 			return m, tea.Quit
 		}
 
 	case stepProject:
-		if len(m.projects) >= 2 {
+		if m.splitMode && m.row > 0 {
+			m.row--
+			m.currentStep = stepTool
+			m.list = m.toolList(m.rows[m.row].Project, m.rows[m.row].Tool)
+		} else if m.splitMode {
+			m.currentStep = stepLayout
+			m.list = m.layoutList()
+		} else if len(m.projects) >= 2 {
 			m.currentStep = stepMode
 			w, h := m.listSize()
 			m.list = newModeList(w, h)
-		} else if len(m.cfg.Presets) > 0 {
-			m.currentStep = stepPreset
-			w, h := m.listSize()
-			m.list = newPresetList(m.cfg.Presets, w, h)
+		} else if m.hasPresetList() {
+			return m.backToPresets(), nil
 		} else {
 			m.cancelled = true
 			return m, tea.Quit
 		}
 
-	case stepProjectBottom:
-		m.currentStep = stepProject
-		w, h := m.listSize()
-		m.list = newProjectList(m.projects, w, h)
-
 	case stepLayout:
 		if m.splitMode {
-			m.currentStep = stepProjectBottom
+			m.currentStep = stepMode
 			w, h := m.listSize()
-			m.list = newProjectList(m.projects, w, h)
+			m.list = newModeList(w, h)
+			m.list.Select(1)
 		} else {
 			m.currentStep = stepProject
-			w, h := m.listSize()
-			m.list = newProjectList(m.projects, w, h)
+			m.list = m.projectListFor(m.selectedProject)
 		}
 
 	case stepTool:
-		m.currentStep = stepLayout
-		w, h := m.listSize()
 		if m.splitMode {
-			m.list = newSplitLayoutList(w, h, m.cfg.DefaultLayout)
+			m.currentStep = stepProject
+			m.list = m.projectListFor(m.rows[m.row].Project)
 		} else {
-			m.list = newLayoutList(m.layouts, w, h, m.cfg.DefaultLayout)
+			m.currentStep = stepLayout
+			m.list = m.layoutList()
 		}
 
-	case stepToolBottom:
-		m.currentStep = stepTool
-		w, h := m.listSize()
-		m.list = newToolList(m.tools, w, h, m.cfg.DefaultTool)
-
 	case stepConfirm:
 		if m.splitMode {
-			m.currentStep = stepToolBottom
-			w, h := m.listSize()
-			m.list = newToolList(m.tools, w, h, m.cfg.DefaultTool)
+			m.row = len(m.rows) - 1
+			m.currentStep = stepTool
+			m.list = m.toolList(m.rows[m.row].Project, m.rows[m.row].Tool)
 		} else {
 			m.currentStep = stepTool
-			w, h := m.listSize()
-			m.list = newToolList(m.tools, w, h, m.cfg.DefaultTool)
+			m.list = m.toolList(m.selectedProject, m.selectedTool)
 		}
+
+	case stepCells:
+		m.currentStep = stepConfirm
+		w, h := m.listSize()
+		m.list = newConfirmList(w, h, m.editingPreset, m.worktrees)
+
+	case stepCellProject:
+		return m.startCells(m.editingCell), nil
+
+	case stepCellTool:
+		m.currentStep = stepCellProject
+		m.list = m.cellProjectList()
 	}
 
 	return m, nil
@@ -493,24 +773,22 @@ func (m Model) updatePresetNaming(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if name == "" {
 			return m, nil
 		}
-		// Save preset
-		preset := config.Preset{
-			Name:    name,
-			Project: m.selectedProject.Name,
-			Layout:  m.selectedLayout.ID(),
-			Tool:    m.selectedTool.Name,
+		if m.renamingPreset != "" {
+			return m.finishRename(name)
 		}
-		if m.splitMode {
-			preset.ProjectBottom = m.selectedBottomProject.Name
-			preset.ToolBottom = m.selectedToolBottom.Name
+		// Save preset
+		preset := m.presetFromSelection(name)
+		if err := m.cfg.AddPreset(preset); err != nil {
+			m.statusMsg = err.Error()
+			return m, nil
 		}
-		m.cfg.Presets = append(m.cfg.Presets, preset)
 		m.configDirty = true
 		m.currentStep = stepDone
 		return m, tea.Quit
 
 	case "esc":
 		m.namingPreset = false
+		m.renamingPreset = ""
 		m.presetInput.Reset()
 		return m, nil
 	}
@@ -520,77 +798,199 @@ func (m Model) updatePresetNaming(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-func (m *Model) applyPreset(p config.Preset) {
-	// Find the project by name
-	for _, proj := range m.projects {
-		if proj.Name == p.Project {
-			m.selectedProject = proj
-			break
+// presetFromSelection builds a preset named name from the current selections.
+func (m Model) presetFromSelection(name string) config.Preset {
+	preset := config.Preset{
+		Name:      name,
+		Layout:    m.selectedLayout.ID(),
+		Worktrees: m.worktrees,
+	}
+	if m.splitMode {
+		for _, r := range m.rows {
+			preset.Rows = append(preset.Rows, config.PresetRow{Project: m.presetProject(r.Project), Tool: r.Tool.Name})
+		}
+	} else {
+		preset.Project = m.presetProject(m.selectedProject)
+		preset.Tool = m.selectedTool.Name
+	}
+	preset.Cells = m.presetCells()
+	return preset
+}
+
+// applyPreset selects the project(s), layout, tool(s) and cell overrides
+// named by p. It fails when any of them no longer exists.
+func (m *Model) applyPreset(p config.Preset) error {
+	var errs []string
+
+	m.splitMode = len(p.Rows) > 0
+	m.worktrees = p.Worktrees
+	if !m.splitMode {
+		proj, err := m.findProject(p.Project)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		m.selectedProject = proj
+
+		tool, err := m.findTool(proj, p.Tool)
+		if err != nil {
+			errs = append(errs, err.Error())
 		}
+		m.selectedTool = tool
 	}
-	// Normalize old-style layout IDs (e.g. "2x1" -> "2", "3x2" -> "3,3")
-	layoutID := convertLegacyLayoutID(p.Layout)
+
 	// Find the layout — search both regular and split layouts
-	for _, lay := range m.layouts {
-		if lay.ID() == layoutID {
+	m.selectedLayout = Layout{}
+	for _, lay := range AllLayouts(m.cfgFor(m.selectedProject)) {
+		if lay.RowCols != nil && lay.ID() == p.Layout {
 			m.selectedLayout = lay
 			break
 		}
 	}
 	if m.selectedLayout.Name == "" {
 		for _, lay := range SplitLayouts {
-			if lay.ID() == layoutID {
+			if lay.ID() == p.Layout {
 				m.selectedLayout = lay
 				break
 			}
 		}
 	}
-	// Find the tool
-	for _, t := range m.tools {
-		if t.Name == p.Tool {
-			m.selectedTool = t
+	if m.selectedLayout.Name == "" {
+		errs = append(errs, fmt.Sprintf("layout %q not found", p.Layout))
+	}
+
+	m.rows = nil
+	m.row = 0
+	for _, r := range p.Rows {
+		proj, err := m.findProject(r.Project)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		tool, err := m.findTool(proj, r.Tool)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		m.rows = append(m.rows, Row{Project: proj, Tool: tool})
+	}
+	if m.splitMode && m.selectedLayout.Name != "" && len(p.Rows) != m.selectedLayout.Groups() {
+		errs = append(errs, fmt.Sprintf("layout %q takes %d projects, preset assigns %d", p.Layout, m.selectedLayout.Groups(), len(p.Rows)))
+	}
+
+	errs = append(errs, m.applyPresetCells(p.Cells)...)
+	for _, c := range m.Cells() {
+		if _, err := m.projectConfig(c.Project); err != nil {
+			errs = append(errs, err.Error())
 			break
 		}
 	}
-	// Detect split preset
-	if p.ProjectBottom != "" {
-		m.splitMode = true
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// findProject looks a project up by name, or by path when name is an
+// absolute path. A path may also name a directory that was not scanned,
+// such as a project in the launch history opened from elsewhere. A name
+// shared by projects under different roots is ambiguous.
+func (m Model) findProject(name string) (scanner.Project, error) {
+	if filepath.IsAbs(name) {
 		for _, proj := range m.projects {
-			if proj.Name == p.ProjectBottom {
-				m.selectedBottomProject = proj
-				break
+			if proj.Path == name {
+				return proj, nil
 			}
 		}
-		for _, t := range m.tools {
-			if t.Name == p.ToolBottom {
-				m.selectedToolBottom = t
-				break
-			}
+		if fi, err := os.Stat(name); err == nil && fi.IsDir() {
+			return withConfigFile(scanner.Project{Name: filepath.Base(name), Path: name}), nil
 		}
+		return scanner.Project{}, fmt.Errorf("project %s not found", name)
 	}
+	var found []scanner.Project
+	for _, proj := range m.projects {
+		if proj.Name == name {
+			found = append(found, proj)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return scanner.Project{}, fmt.Errorf("project %q not found", name)
+	case 1:
+		return found[0], nil
+	}
+	paths := make([]string, len(found))
+	for i, proj := range found {
+		paths[i] = proj.Path
+	}
+	return scanner.Project{}, fmt.Errorf("project %q is ambiguous, use one of %s", name, strings.Join(paths, ", "))
 }
 
-// convertLegacyLayoutID converts old "CxR" format to new comma-separated format.
-// e.g. "2x1" -> "2", "2x2" -> "2,2", "3x2" -> "3,3", "4x2" -> "4,4"
-// New format IDs like "3,4" pass through unchanged.
-func convertLegacyLayoutID(id string) string {
-	if !strings.Contains(id, "x") {
-		return id
+// presetProject is how a preset names p: by name, or by path when a
+// project under another root has the same name, so the preset still
+// opens p.
+func (m Model) presetProject(p scanner.Project) string {
+	for _, proj := range m.projects {
+		if proj.Name == p.Name && proj.Path != p.Path {
+			return p.Path
+		}
 	}
-	parts := strings.SplitN(id, "x", 2)
-	if len(parts) != 2 {
-		return id
+	return p.Name
+}
+
+// findTool looks a tool up by name among the tools offered for p. An
+// empty name or "None" means no tool.
+func (m Model) findTool(p scanner.Project, name string) (Tool, error) {
+	if name == "" || name == "None" {
+		return BuiltinTools[0], nil
 	}
-	cols, err1 := strconv.Atoi(parts[0])
-	rows, err2 := strconv.Atoi(parts[1])
-	if err1 != nil || err2 != nil {
-		return id
+	for _, t := range m.toolsFor(p) {
+		if t.Name == name {
+			return t, nil
+		}
 	}
-	rowCols := make([]string, rows)
-	for i := range rowCols {
-		rowCols[i] = strconv.Itoa(cols)
+	return Tool{}, fmt.Errorf("tool %q not found", name)
+}
+
+// ResolvePreset applies p the way picking it from the preset list does,
+// without running the wizard. Repository config files that run commands
+// must already be in trusted. The returned model exposes the selections
+// through the same accessors as a finished wizard.
+func ResolvePreset(projects []scanner.Project, cfg *config.Config, trusted *trust.Store, p config.Preset) (Model, error) {
+	m := NewModel(projects, cfg, "", nil, trusted)
+	if err := m.applyPreset(p); err != nil {
+		return m, err
 	}
-	return strings.Join(rowCols, ",")
+	m.selectedPreset = &p
+	m.currentStep = stepDone
+	return m, nil
+}
+
+// CheckConfig validates cfg against the projects found, the built-in
+// tools and layouts, and the custom commands of the projects' repository
+// config files. Nil projects skip checking the projects of presets.
+// Unreadable repository config files are left to the wizard to report.
+func CheckConfig(cfg *config.Config, projects []scanner.Project) []config.Problem {
+	var names config.Names
+	if projects != nil {
+		names.Projects = make([]string, 0, len(projects))
+	}
+	for _, p := range projects {
+		names.Projects = append(names.Projects, p.Name)
+		if p.ConfigFile == "" {
+			continue
+		}
+		if pc, err := config.LoadProject(p.Path); err == nil && pc != nil {
+			for name := range pc.CustomCommands {
+				names.Tools = append(names.Tools, name)
+			}
+		}
+	}
+	for _, t := range BuiltinTools {
+		names.Tools = append(names.Tools, t.Name)
+	}
+	for _, l := range append(append([]Layout(nil), Layouts...), SplitLayouts...) {
+		names.Layouts = append(names.Layouts, l.ID())
+	}
+	return cfg.Validate(names)
 }
 
 func (m Model) updateCustomLayoutInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -607,7 +1007,7 @@ func (m Model) updateCustomLayoutInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		layout := Layout{RowCols: rowCols}
 		layout.Desc = layout.GenerateDesc()
 		layout.Name = fmt.Sprintf("Custom %s", layout.ID())
-		m.selectedLayout = layout
+		m.setLayout(layout)
 
 		// Save to config
 		m.cfg.CustomLayouts = append(m.cfg.CustomLayouts, config.CustomLayout{
@@ -615,15 +1015,10 @@ func (m Model) updateCustomLayoutInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			RowCols: rowCols,
 		})
 		m.configDirty = true
-		// Refresh layouts list
-		m.layouts = AllLayouts(m.cfg)
 
 		m.enteringCustomLayout = false
 		m.customLayoutInput.Reset()
-		m.currentStep = stepTool
-		w, h := m.listSize()
-		m.list = newToolList(m.tools, w, h, m.cfg.DefaultTool)
-		return m, nil
+		return m.afterLayout(), nil
 
 	case "esc":
 		m.enteringCustomLayout = false
@@ -665,7 +1060,7 @@ func parseRowCols(s string) ([]int, error) {
 		result = append(result, n)
 		total += n
 	}
-	if len(result) == 0 || total > 20 {
+	if len(result) == 0 || total > config.MaxCells {
 		return nil, fmt.Errorf("invalid layout")
 	}
 	return result, nil
@@ -674,21 +1069,7 @@ func parseRowCols(s string) ([]int, error) {
 func (m Model) selectionLineCount() int {
 	count := 0
 	if m.splitMode {
-		if m.currentStep > stepProject {
-			count++ // "Top: xxx"
-		}
-		if m.currentStep > stepProjectBottom {
-			count++ // "Bottom: xxx"
-		}
-		if m.currentStep > stepLayout {
-			count++ // "Layout: xxx"
-		}
-		if m.currentStep > stepTool {
-			count++ // "Top Tool: xxx"
-		}
-		if m.currentStep > stepToolBottom {
-			count++ // "Btm Tool: xxx"
-		}
+		count += len(m.splitSelections())
 	} else {
 		if m.currentStep > stepProject {
 			count++ // "Project: xxx"
@@ -706,10 +1087,41 @@ func (m Model) selectionLineCount() int {
 	return count
 }
 
+// projectListFor builds the project list. While a preset is being edited
+// the cursor starts on selected.
+func (m Model) projectListFor(selected scanner.Project) list.Model {
+	if m.editingPreset != "" {
+		return m.projectList(selected.Path)
+	}
+	return m.projectList("")
+}
+
+// layoutDefault is the layout the layout list starts on: the edited
+// preset's layout, otherwise the default of the selected project's config
+// or the global one.
+func (m Model) layoutDefault() string {
+	if m.editingPreset != "" && m.selectedLayout.Name != "" {
+		return m.selectedLayout.ID()
+	}
+	return m.cfgFor(m.selectedProject).DefaultLayout
+}
+
+// toolList builds the tool list for project p, starting on selected while
+// a preset is being edited, otherwise on the default of p's config or the
+// global one.
+func (m Model) toolList(p scanner.Project, selected Tool) list.Model {
+	w, h := m.listSize()
+	def := m.cfgFor(p).DefaultTool
+	if m.editingPreset != "" && selected.Name != "" {
+		def = selected.Name
+	}
+	return newToolList(m.toolsFor(p), w, h, def)
+}
+
 func (m Model) listSize() (int, int) {
 	h, v := appStyle.GetFrameSize()
 	w := m.width - h
-	overhead := 6 + m.selectionLineCount()
+	overhead := 6 + m.selectionLineCount() + m.warningLineCount()
 	lh := m.height - v - overhead
 	if w < 30 {
 		w = 60
@@ -729,5 +1141,7 @@ func (m Model) SelectedProject() scanner.Project    { return m.selectedProject }
 func (m Model) SelectedLayout() Layout              { return m.selectedLayout }
 func (m Model) SelectedTool() Tool                  { return m.selectedTool }
 func (m Model) IsSplitMode() bool                   { return m.splitMode }
-func (m Model) SelectedBottomProject() scanner.Project { return m.selectedBottomProject }
-func (m Model) SelectedToolBottom() Tool            { return m.selectedToolBottom }
+func (m Model) Rows() []Row                         { return m.rows }
+func (m Model) Worktrees() bool                     { return m.worktrees }
+func (m Model) TrustStore() *trust.Store            { return m.trust }
+func (m Model) TrustChanged() bool                  { return m.trustChanged }
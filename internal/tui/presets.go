@@ -0,0 +1,246 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"agent-t/internal/config"
+	"agent-t/internal/history"
+	"agent-t/internal/scanner"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// updatePresetKeys handles the management keys on the preset list. ok is
+// false when msg is not one of them and should go to the list instead.
+func (m Model) updatePresetKeys(msg tea.KeyMsg) (model tea.Model, cmd tea.Cmd, ok bool) {
+	selected := m.list.SelectedItem()
+	if selected == nil {
+		return m, nil, false
+	}
+	item := selected.(presetItem)
+	if item.isNew || item.last {
+		return m, nil, false
+	}
+	if item.from != "" && (key.Matches(msg, presetKeys.Delete) || key.Matches(msg, presetKeys.Rename) || key.Matches(msg, presetKeys.Edit)) {
+		m.statusMsg = fmt.Sprintf("Preset %q is read-only: it comes from %s. Press c to make your own copy.", item.preset.Name, shortenHome(item.from))
+		return m, nil, true
+	}
+
+	switch {
+	case key.Matches(msg, presetKeys.Delete):
+		m.deletingPreset = item.preset.Name
+		return m, nil, true
+
+	case key.Matches(msg, presetKeys.Rename):
+		m.renamingPreset = item.preset.Name
+		m.namingPreset = true
+		m.presetInput.SetValue(item.preset.Name)
+		m.presetInput.CursorEnd()
+		return m, m.presetInput.Focus(), true
+
+	case key.Matches(msg, presetKeys.Duplicate):
+		dup := item.preset
+		dup.Name = m.copyName(item.preset.Name)
+		if err := m.cfg.AddPreset(dup); err != nil {
+			m.statusMsg = err.Error()
+			return m, nil, true
+		}
+		m.configDirty = true
+		m.refreshPresetList(dup.Name)
+		return m, nil, true
+
+	case key.Matches(msg, presetKeys.Edit):
+		return m.startEdit(item.preset), nil, true
+	}
+	return m, nil, false
+}
+
+func (m Model) updateDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	name := m.deletingPreset
+	m.deletingPreset = ""
+	switch msg.String() {
+	case "y", "Y":
+		if err := m.cfg.DeletePreset(name); err != nil {
+			m.statusMsg = err.Error()
+			return m, nil
+		}
+		m.configDirty = true
+		m.refreshPresetList("")
+	case "ctrl+c":
+		m.cancelled = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m Model) deleteConfirmView() string {
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(promptStyle.Render(fmt.Sprintf("Delete preset %q?", m.deletingPreset)))
+	b.WriteString("\n\n")
+	b.WriteString(dimStyle.Render("y to delete • any other key to cancel"))
+	return b.String()
+}
+
+func (m Model) finishRename(name string) (tea.Model, tea.Cmd) {
+	if err := m.cfg.RenamePreset(m.renamingPreset, name); err != nil {
+		m.statusMsg = err.Error()
+		return m, nil
+	}
+	m.configDirty = true
+	m.namingPreset = false
+	m.renamingPreset = ""
+	m.presetInput.Reset()
+	m.presetInput.Blur()
+	m.refreshPresetList(name)
+	return m, nil
+}
+
+// copyName returns the first free name of the form "name copy", "name copy 2", ...
+func (m Model) copyName(name string) string {
+	candidate := name + " copy"
+	for i := 2; ; i++ {
+		if _, exists := m.cfg.FindPreset(candidate); !exists {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s copy %d", name, i)
+	}
+}
+
+// startEdit reopens the wizard pre-filled with p. Selections that no longer
+// resolve are left empty and reported, so they can be picked again.
+func (m Model) startEdit(p config.Preset) Model {
+	if err := m.applyPreset(p); err != nil {
+		m.statusMsg = fmt.Sprintf("Preset %q: %v", p.Name, err)
+	}
+	m.editingPreset = p.Name
+	w, h := m.listSize()
+	if len(m.projects) >= 2 {
+		m.currentStep = stepMode
+		m.list = newModeList(w, h)
+		if m.splitMode {
+			m.list.Select(1)
+		}
+	} else {
+		m.currentStep = stepProject
+		m.list = m.projectListFor(m.selectedProject)
+	}
+	return m
+}
+
+// updateEditedPreset overwrites the preset being edited with the current
+// selections.
+func (m *Model) updateEditedPreset() error {
+	preset := m.presetFromSelection(m.editingPreset)
+	if err := m.cfg.UpdatePreset(m.editingPreset, preset); err != nil {
+		return err
+	}
+	m.configDirty = true
+	return nil
+}
+
+// backToPresets returns to the preset list, dropping any selections made
+// in the wizard.
+func (m Model) backToPresets() Model {
+	selected := m.editingPreset
+	m.editingPreset = ""
+	m.splitMode = false
+	m.selectedProject = scanner.Project{}
+	m.selectedLayout = Layout{}
+	m.selectedTool = Tool{}
+	m.rows = nil
+	m.row = 0
+	m.worktrees = false
+	m.cells = nil
+	m.currentStep = stepPreset
+	m.refreshPresetList(selected)
+	return m
+}
+
+// refreshPresetList rebuilds the preset list with the cursor on the preset
+// called selected, or on the first preset when it is empty.
+func (m *Model) refreshPresetList(selected string) {
+	w, h := m.listSize()
+	last := m.lastItem()
+	m.list = newPresetList(m.cfg, last, w, h)
+	first := 1 // after "New workspace..."
+	if last != nil {
+		first++
+	}
+	for i, p := range m.cfg.Presets {
+		if p.Name == selected {
+			m.list.Select(first + i)
+		}
+	}
+}
+
+// hasPresetList reports whether the wizard starts on the preset list:
+// when there are presets or a last launch to offer.
+func (m Model) hasPresetList() bool {
+	return len(m.cfg.Presets) > 0 || m.last != nil
+}
+
+// lastItem is the preset list entry that launches the last workspace
+// again, or nil when nothing has been launched yet.
+func (m Model) lastItem() *presetItem {
+	if m.last == nil {
+		return nil
+	}
+	p := m.last.Preset()
+
+	// Summarize by project name rather than the recorded paths
+	display := p
+	display.Project = m.projectName(p.Project)
+	display.Rows = nil
+	for _, r := range p.Rows {
+		display.Rows = append(display.Rows, config.PresetRow{Project: m.projectName(r.Project), Tool: r.Tool})
+	}
+	desc := fmt.Sprintf("%s | %s", display.Summary(), ago(m.last.Time, time.Now()))
+	return &presetItem{preset: p, last: true, desc: desc}
+}
+
+// projectName is the name of the project at path, or its directory name
+// when it was not scanned.
+func (m Model) projectName(path string) string {
+	if path == "" {
+		return ""
+	}
+	for _, p := range m.projects {
+		if p.Path == path {
+			return p.Name
+		}
+	}
+	return filepath.Base(path)
+}
+
+// HistoryEntry records the selections as a launch for the history at now.
+// Projects are recorded by path, so the workspace can be launched again
+// from any directory.
+func (m Model) HistoryEntry(now time.Time) history.Entry {
+	e := history.Entry{
+		Time:      now,
+		Layout:    m.selectedLayout.ID(),
+		Split:     m.splitMode,
+		Worktrees: m.worktrees,
+	}
+	if m.splitMode {
+		for _, r := range m.rows {
+			e.Projects = append(e.Projects, r.Project.Path)
+			e.Tools = append(e.Tools, r.Tool.Name)
+		}
+	} else {
+		e.Projects = []string{m.selectedProject.Path}
+		e.Tools = []string{m.selectedTool.Name}
+	}
+	e.Cells = m.presetCells()
+	for i := range e.Cells {
+		if p := m.cells[i].project; p != nil {
+			e.Cells[i].Project = p.Path
+		}
+	}
+	return e
+}
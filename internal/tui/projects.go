@@ -0,0 +1,281 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"agent-t/internal/config"
+	"agent-t/internal/scanner"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// projectKeys are the extra keys on the project list.
+var projectKeys = struct {
+	Sort key.Binding
+}{
+	Sort: key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sort")),
+}
+
+// rankedProjects orders the projects for the project list: by frecency,
+// the projects launched most often and most recently first, or by name
+// once the sort key has been pressed. Projects never launched follow in
+// name order.
+func (m Model) rankedProjects() []scanner.Project {
+	projects := append([]scanner.Project(nil), m.projects...)
+	if m.sortByName {
+		return projects
+	}
+	sort.SliceStable(projects, func(i, j int) bool {
+		return m.frecency[projects[i].Path] > m.frecency[projects[j].Path]
+	})
+	return projects
+}
+
+// projectList builds the project list with the cursor on the project at
+// path, or the first one when path is not listed.
+func (m Model) projectList(path string) list.Model {
+	w, h := m.listSize()
+	projects := m.rankedProjects()
+	l := newProjectList(projects, w, h, m.sortByName || len(m.frecency) == 0)
+	for i, p := range projects {
+		if p.Path == path {
+			l.Select(i)
+		}
+	}
+	return l
+}
+
+// updateProjectKeys handles the sort key on the project lists. ok is false
+// when msg is not one of them and should go to the list instead.
+func (m Model) updateProjectKeys(msg tea.KeyMsg) (model tea.Model, cmd tea.Cmd, ok bool) {
+	if !key.Matches(msg, projectKeys.Sort) {
+		return m, nil, false
+	}
+	var path string
+	if selected := m.list.SelectedItem(); selected != nil {
+		path = selected.(projectItem).project.Path
+	}
+	m.sortByName = !m.sortByName
+	m.list = m.projectList(path)
+	return m, nil, true
+}
+
+// projectsInspectedMsg carries the projects with their git state and
+// language filled in.
+type projectsInspectedMsg []scanner.Project
+
+// inspectProjects inspects a copy of the projects in the background, as
+// inspecting many checkouts takes a while.
+func (m Model) inspectProjects() tea.Cmd {
+	if len(m.projects) == 0 {
+		return nil
+	}
+	projects := append([]scanner.Project(nil), m.projects...)
+	return func() tea.Msg {
+		scanner.Inspect(projects, 0)
+		return projectsInspectedMsg(projects)
+	}
+}
+
+// setProjectInfo copies the git state and language of the inspected
+// projects to the model and the project list on screen, keeping its
+// cursor and filter.
+func (m *Model) setProjectInfo(inspected projectsInspectedMsg) tea.Cmd {
+	byPath := make(map[string]scanner.Project, len(inspected))
+	for _, p := range inspected {
+		byPath[p.Path] = p
+	}
+	withInfo := func(p scanner.Project) scanner.Project {
+		if i, ok := byPath[p.Path]; ok {
+			p.Git, p.Language = i.Git, i.Language
+		}
+		return p
+	}
+	for i, p := range m.projects {
+		m.projects[i] = withInfo(p)
+	}
+
+	var cmds []tea.Cmd
+	for i, item := range m.list.Items() {
+		if pi, ok := item.(projectItem); ok {
+			cmds = append(cmds, m.list.SetItem(i, projectItem{project: withInfo(pi.project)}))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// projectConfig is a project's config.ProjectFile as read, or the error
+// reading it.
+type projectConfig struct {
+	cfg *config.ProjectConfig
+	err error
+}
+
+// loadProjectConfig returns the config file of project p, nil when it has
+// none. The file is read once and kept for the lifetime of the model.
+func (m Model) loadProjectConfig(p scanner.Project) (*config.ProjectConfig, error) {
+	if p.ConfigFile == "" {
+		return nil, nil
+	}
+	pc, ok := m.projectCfgs[p.Path]
+	if !ok {
+		pc.cfg, pc.err = config.LoadProject(p.Path)
+		m.projectCfgs[p.Path] = pc
+	}
+	return pc.cfg, pc.err
+}
+
+// projectConfig returns the config file of project p that applies: nil
+// when p has none or it was declined, and an error when it cannot be read
+// or runs commands without having been trusted.
+func (m Model) projectConfig(p scanner.Project) (*config.ProjectConfig, error) {
+	pc, err := m.loadProjectConfig(p)
+	if pc == nil || err != nil || m.ignoredCfgs[p.ConfigFile] {
+		return nil, err
+	}
+	if len(pc.Commands()) > 0 && !m.trust.Trusted(p.ConfigFile, pc.Hash) {
+		return nil, fmt.Errorf("%s runs commands and is not trusted; select the project in agent-t to review it", p.ConfigFile)
+	}
+	return pc, nil
+}
+
+// needsTrust reports whether p's config file runs commands and awaits
+// approval.
+func (m Model) needsTrust(p scanner.Project) bool {
+	pc, err := m.loadProjectConfig(p)
+	return pc != nil && err == nil && !m.ignoredCfgs[p.ConfigFile] &&
+		len(pc.Commands()) > 0 && !m.trust.Trusted(p.ConfigFile, pc.Hash)
+}
+
+// untrustedCell returns the project of the first cell whose config file
+// awaits approval.
+func (m Model) untrustedCell() (scanner.Project, bool) {
+	for _, c := range m.Cells() {
+		if m.needsTrust(c.Project) {
+			return c.Project, true
+		}
+	}
+	return scanner.Project{}, false
+}
+
+// askTrust shows the commands of p's config file for approval. The answer
+// repeats the step that needed it.
+func (m Model) askTrust(p scanner.Project) (tea.Model, tea.Cmd) {
+	m.trusting = &p
+	return m, nil
+}
+
+func (m Model) updateTrustPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := *m.trusting
+	m.trusting = nil
+	switch msg.String() {
+	case "y", "Y":
+		pc, _ := m.loadProjectConfig(p)
+		m.trust.Trust(p.ConfigFile, pc.Hash, time.Now())
+		m.trustChanged = true
+		return m.advance()
+	case "n", "N":
+		m.ignoredCfgs[p.ConfigFile] = true
+		return m.advance()
+	case "ctrl+c":
+		m.cancelled = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m Model) trustView() string {
+	pc, _ := m.loadProjectConfig(*m.trusting)
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(promptStyle.Render(fmt.Sprintf("%s wants to run:", shortenHome(m.trusting.ConfigFile))))
+	b.WriteString("\n\n")
+	for _, cmd := range pc.Commands() {
+		b.WriteString("  " + cmd + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("y to trust it • n to ignore the file this time • any other key to cancel"))
+	return b.String()
+}
+
+// projectConfigError describes why p's config file is ignored, or is
+// empty when it was read.
+func (m Model) projectConfigError(p scanner.Project) string {
+	if _, err := m.projectConfig(p); err != nil {
+		return fmt.Sprintf("Ignoring %v", err)
+	}
+	return ""
+}
+
+// cfgFor is the config that applies to project p: the global config with
+// p's config file layered on top. A file that cannot be read is ignored;
+// selecting the project reports it.
+func (m Model) cfgFor(p scanner.Project) *config.Config {
+	pc, _ := m.projectConfig(p)
+	return m.cfg.ForProject(pc)
+}
+
+// toolsFor lists the tools offered for project p.
+func (m Model) toolsFor(p scanner.Project) []Tool {
+	return AllTools(m.cfgFor(p))
+}
+
+// withConfigFile sets p.ConfigFile when the project directory has a config
+// file, for projects that were not found by the scanner.
+func withConfigFile(p scanner.Project) scanner.Project {
+	file := filepath.Join(p.Path, config.ProjectFile)
+	if _, err := os.Stat(file); err == nil {
+		p.ConfigFile = file
+	}
+	return p
+}
+
+// applyProjectCell applies the default of p's config for the cell at index
+// i among the cells opening p, then p's env, under anything already set.
+// The default's command or tool only replaces the row's tool when that is
+// no tool or p's own default tool; any other tool was chosen for the row.
+func (m Model) applyProjectCell(cell *Cell, p scanner.Project, i int) {
+	pc, _ := m.projectConfig(p)
+	if pc == nil {
+		return
+	}
+	cell.Setup = pc.Setup
+	if i < len(pc.Cells) {
+		d := pc.Cells[i]
+		if chosen := cell.Tool.Command != "" && cell.Tool.Name != pc.DefaultTool; !chosen {
+			if d.Command != "" {
+				cell.Tool = Tool{Command: d.Command}
+			} else if d.Tool != "" {
+				if tool, err := m.findTool(p, d.Tool); err == nil {
+					cell.Tool = tool
+				}
+			}
+		}
+		if d.Title != "" {
+			cell.Title = d.Title
+		}
+		if len(d.Env) > 0 {
+			cell.Env = merge(d.Env, cell.Env)
+		}
+	}
+	if len(pc.Env) > 0 {
+		cell.Env = merge(pc.Env, cell.Env)
+	}
+}
+
+// merge returns base with the entries of over added, replacing those of
+// the same key.
+func merge(base, over map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(over))
+	maps.Copy(merged, base)
+	maps.Copy(merged, over)
+	return merged
+}
@@ -1,78 +1,87 @@
 package tui
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"agent-t/internal/config"
 	"agent-t/internal/scanner"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 )
 
 type step int
 
 const (
-	stepPreset        step = iota
-	stepMode          step = iota
-	stepProject       step = iota
-	stepProjectBottom step = iota
-	stepLayout        step = iota
-	stepTool          step = iota
-	stepToolBottom    step = iota
-	stepConfirm       step = iota
-	stepDone          step = iota
+	stepPreset      step = iota
+	stepMode        step = iota
+	stepProject     step = iota
+	stepLayout      step = iota
+	stepTool        step = iota
+	stepConfirm     step = iota
+	stepCells       step = iota
+	stepCellProject step = iota
+	stepCellTool    step = iota
+	stepDone        step = iota
 )
 
-func stepTitle(s step, splitMode bool) string {
+// stepTitle names a step. group is the row or column being assigned in
+// split mode, e.g. "Row 2", and empty in single mode.
+func stepTitle(s step, group string) string {
 	switch s {
 	case stepPreset:
 		return "Choose a Preset"
 	case stepMode:
 		return "Workspace Mode"
 	case stepProject:
-		if splitMode {
-			return "Select Top Project"
+		if group != "" {
+			return "Select Project for " + group
 		}
 		return "Select Project"
-	case stepProjectBottom:
-		return "Select Bottom Project"
 	case stepLayout:
 		return "Select Layout"
 	case stepTool:
-		if splitMode {
-			return "Select Top Tool"
+		if group != "" {
+			return "Select Tool for " + group
 		}
 		return "Select AI Tool"
-	case stepToolBottom:
-		return "Select Bottom Tool"
 	case stepConfirm:
 		return "Confirm & Launch"
+	case stepCells:
+		return "Customize Cells"
+	case stepCellProject:
+		return "Select Cell Project"
+	case stepCellTool:
+		return "Select Cell Tool"
 	default:
 		return ""
 	}
 }
 
-func stepNumber(s step, hasPresets bool, splitMode bool) (int, int) {
-	if splitMode {
-		total := 7
+// stepNumber numbers a step out of the total. A split workspace picks its
+// layout first, then a project and a tool for each of its groups, so
+// groups > 0 selects split mode and group is the one being assigned.
+func stepNumber(s step, hasPresets bool, groups, group int) (int, int) {
+	if groups > 0 {
+		total := 2 + 2*groups + 1
 		switch s {
 		case stepPreset:
 			return 0, total
 		case stepMode:
 			return 1, total
-		case stepProject:
-			return 2, total
-		case stepProjectBottom:
-			return 3, total
 		case stepLayout:
-			return 4, total
+			return 2, total
+		case stepProject:
+			return 3 + 2*group, total
 		case stepTool:
-			return 5, total
-		case stepToolBottom:
-			return 6, total
-		case stepConfirm:
-			return 7, total
+			return 4 + 2*group, total
+		case stepConfirm, stepCells, stepCellProject, stepCellTool:
+			return total, total
 		}
 		return 0, total
 	}
@@ -89,7 +98,7 @@ func stepNumber(s step, hasPresets bool, splitMode bool) (int, int) {
 		return 2, total
 	case stepTool:
 		return 3, total
-	case stepConfirm:
+	case stepConfirm, stepCells, stepCellProject, stepCellTool:
 		return 4, total
 	}
 	return 0, total
@@ -113,6 +122,31 @@ func (l Layout) TotalTerminals() int {
 
 func (l Layout) NumRows() int { return len(l.RowCols) }
 
+// Groups is how many projects a split workspace with this layout takes:
+// one per row, or one per column when the layout has a single row.
+func (l Layout) Groups() int {
+	if len(l.RowCols) == 1 {
+		return l.RowCols[0]
+	}
+	return len(l.RowCols)
+}
+
+// GroupOf returns the group of the cell at row r, column c.
+func (l Layout) GroupOf(r, c int) int {
+	if len(l.RowCols) == 1 {
+		return c
+	}
+	return r
+}
+
+// GroupName names group i for display, e.g. "Row 2" or "Column 1".
+func (l Layout) GroupName(i int) string {
+	if len(l.RowCols) == 1 {
+		return fmt.Sprintf("Column %d", i+1)
+	}
+	return fmt.Sprintf("Row %d", i+1)
+}
+
 func (l Layout) ID() string {
 	parts := make([]string, len(l.RowCols))
 	for i, c := range l.RowCols {
@@ -143,10 +177,14 @@ var Layouts = []Layout{
 }
 
 // AllLayouts returns built-in layouts + custom layouts from config + a "Custom..." entry.
+// Custom layouts with an invalid row_cols are left out; Validate reports them.
 func AllLayouts(cfg *config.Config) []Layout {
 	layouts := make([]Layout, len(Layouts))
 	copy(layouts, Layouts)
 	for _, cl := range cfg.CustomLayouts {
+		if config.CheckRowCols(cl.RowCols) != nil {
+			continue
+		}
 		l := Layout{Name: cl.Name, RowCols: cl.RowCols, Custom: true}
 		l.Desc = l.GenerateDesc()
 		layouts = append(layouts, l)
@@ -170,6 +208,13 @@ var BuiltinTools = []Tool{
 	{Name: "OpenCode", Command: "opencode"},
 }
 
+// Row is the project and tool assigned to one row, or one column of a
+// single-row layout, in split workspace mode.
+type Row struct {
+	Project scanner.Project
+	Tool    Tool
+}
+
 func AllTools(cfg *config.Config) []Tool {
 	tools := make([]Tool, len(BuiltinTools))
 	copy(tools, BuiltinTools)
@@ -179,11 +224,37 @@ func AllTools(cfg *config.Config) []Tool {
 	return tools
 }
 
-// SplitLayouts contains only multi-row layouts suitable for split workspace mode.
+// SplitLayouts are the built-in layouts offered in split workspace mode.
+// Each row, or each column of a single-row layout, gets its own project.
 var SplitLayouts = []Layout{
 	{Name: "4 terminals (2+2)", RowCols: []int{2, 2}, Desc: "[ ][ ] / [ ][ ]"},
 	{Name: "6 terminals (3+3)", RowCols: []int{3, 3}, Desc: "[ ][ ][ ] / [ ][ ][ ]"},
 	{Name: "8 terminals (4+4)", RowCols: []int{4, 4}, Desc: "[ ][ ][ ][ ] / [ ][ ][ ][ ]"},
+	{Name: "3 rows (1+1+1)", RowCols: []int{1, 1, 1}, Desc: "[ ] / [ ] / [ ]"},
+	{Name: "6 terminals (2+2+2)", RowCols: []int{2, 2, 2}, Desc: "[ ][ ] / [ ][ ] / [ ][ ]"},
+	{Name: "9 terminals (3+3+3)", RowCols: []int{3, 3, 3}, Desc: "[ ][ ][ ] / [ ][ ][ ] / [ ][ ][ ]"},
+	{Name: "4 rows (1+1+1+1)", RowCols: []int{1, 1, 1, 1}, Desc: "[ ] / [ ] / [ ] / [ ]"},
+	{Name: "2 columns", RowCols: []int{2}, Desc: "[ ][ ]"},
+	{Name: "3 columns", RowCols: []int{3}, Desc: "[ ][ ][ ]"},
+	{Name: "4 columns", RowCols: []int{4}, Desc: "[ ][ ][ ][ ]"},
+}
+
+// AllSplitLayouts returns the built-in split layouts, custom layouts from
+// config with a valid row_cols and at least two rows or columns, and a
+// "Custom..." entry.
+func AllSplitLayouts(cfg *config.Config) []Layout {
+	layouts := make([]Layout, len(SplitLayouts))
+	copy(layouts, SplitLayouts)
+	for _, cl := range cfg.CustomLayouts {
+		l := Layout{Name: cl.Name, RowCols: cl.RowCols, Custom: true}
+		if config.CheckRowCols(cl.RowCols) != nil || l.Groups() < 2 {
+			continue
+		}
+		l.Desc = l.GenerateDesc()
+		layouts = append(layouts, l)
+	}
+	layouts = append(layouts, Layout{Name: "Custom...", Desc: "Define your own layout"})
+	return layouts
 }
 
 // --- List item types ---
@@ -201,18 +272,30 @@ func (i modeItem) FilterValue() string { return i.name }
 type presetItem struct {
 	preset config.Preset
 	isNew  bool
+	last   bool   // the most recent launch, replayed like a preset
+	desc   string // description of the last launch
+	from   string // included or system-wide file a read-only preset comes from
 }
 
 func (i presetItem) Title() string {
 	if i.isNew {
 		return "New workspace..."
 	}
+	if i.last {
+		return "Last workspace"
+	}
 	return i.preset.Name
 }
 func (i presetItem) Description() string {
 	if i.isNew {
 		return "Start fresh — pick project, layout, tool"
 	}
+	if i.last {
+		return i.desc
+	}
+	if i.from != "" {
+		return i.preset.Summary() + " | read-only, from " + shortenHome(i.from)
+	}
 	return i.preset.Summary()
 }
 func (i presetItem) FilterValue() string { return i.Title() }
@@ -222,9 +305,84 @@ type projectItem struct {
 }
 
 func (i projectItem) Title() string       { return i.project.Name }
-func (i projectItem) Description() string { return i.project.Path }
 func (i projectItem) FilterValue() string { return i.project.Name }
 
+// Description sums up the project's language and git state, and, as
+// projects can come from several roots, which root it was found in. The
+// path is shown when nothing else is known about the project.
+func (i projectItem) Description() string {
+	return projectSummary(i.project, time.Now())
+}
+
+func projectSummary(p scanner.Project, now time.Time) string {
+	var parts []string
+	if p.Language != "" {
+		parts = append(parts, p.Language)
+	}
+	if g := p.Git; g != nil {
+		branch := g.Branch
+		if g.Ahead > 0 {
+			branch += fmt.Sprintf(" ↑%d", g.Ahead)
+		}
+		if g.Behind > 0 {
+			branch += fmt.Sprintf(" ↓%d", g.Behind)
+		}
+		parts = append(parts, branch)
+		if g.Dirty > 0 {
+			parts = append(parts, fmt.Sprintf("%d changed", g.Dirty))
+		} else {
+			parts = append(parts, "clean")
+		}
+		if !g.LastCommit.IsZero() {
+			parts = append(parts, ago(g.LastCommit, now))
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, shortenHome(p.Path))
+	}
+	if p.ConfigFile != "" {
+		parts = append(parts, config.ProjectFile)
+	}
+	if p.Root != "" {
+		parts = append(parts, "from "+shortenHome(p.Root))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// ago formats how long before now t was, in its largest whole unit.
+func ago(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	default:
+		return fmt.Sprintf("%dy ago", int(d.Hours()/24/365))
+	}
+}
+
+// shortenHome replaces the home directory at the start of path with ~.
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return filepath.Join("~", rest)
+	}
+	return path
+}
+
 type layoutItem struct {
 	layout Layout
 }
@@ -265,7 +423,7 @@ func (i confirmItem) FilterValue() string { return i.name }
 func newModeList(width, height int) list.Model {
 	items := []list.Item{
 		modeItem{name: "Single Project", desc: "All terminals in one project", split: false},
-		modeItem{name: "Split Workspace", desc: "Top row = project A, bottom row = project B", split: true},
+		modeItem{name: "Split Workspace", desc: "Each row (or column) gets its own project and tool", split: true},
 	}
 	l := list.New(items, newStyledDelegate(), width, height)
 	l.Title = "Workspace Mode"
@@ -275,51 +433,60 @@ func newModeList(width, height int) list.Model {
 	return l
 }
 
-func newSplitLayoutList(width, height int, defaultLayout string) list.Model {
-	items := make([]list.Item, len(SplitLayouts))
-	defaultIdx := 0
-	for i, lay := range SplitLayouts {
-		items[i] = layoutItem{layout: lay}
-		if lay.ID() == defaultLayout {
-			defaultIdx = i
-		}
-	}
-	l := list.New(items, newStyledDelegate(), width, height)
-	l.Title = "Layout"
-	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false)
-	l.SetShowHelp(true)
-	l.Select(defaultIdx)
-	return l
+// presetKeys are the management keys on the preset list.
+var presetKeys = struct {
+	Edit, Rename, Duplicate, Delete key.Binding
+}{
+	Edit:      key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit")),
+	Rename:    key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "rename")),
+	Duplicate: key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "duplicate")),
+	Delete:    key.NewBinding(key.WithKeys("x", "delete"), key.WithHelp("x", "delete")),
 }
 
-func newPresetList(presets []config.Preset, width, height int) list.Model {
-	items := []list.Item{presetItem{isNew: true}}
+// newPresetList lists cfg's presets after "New workspace...", and last,
+// when not nil, before it. Presets from included and system-wide files are
+// marked read-only. The cursor starts on last, or else the first preset.
+func newPresetList(cfg *config.Config, last *presetItem, width, height int) list.Model {
+	presets := cfg.Presets
+	var items []list.Item
+	if last != nil {
+		items = append(items, *last)
+	}
+	items = append(items, presetItem{isNew: true})
 	for _, p := range presets {
-		items = append(items, presetItem{preset: p})
+		items = append(items, presetItem{preset: p, from: cfg.ReadOnlyFrom(p.Name)})
 	}
 	l := list.New(items, newStyledDelegate(), width, height)
 	l.Title = "Presets"
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(len(presets) > 5)
 	l.SetShowHelp(true)
-	// Put cursor on first preset (index 1), not "New workspace..."
-	if len(presets) > 0 {
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{presetKeys.Edit, presetKeys.Rename, presetKeys.Duplicate, presetKeys.Delete}
+	}
+	// Put cursor on the last launch or the first preset, not "New workspace..."
+	if last == nil && len(presets) > 0 {
 		l.Select(1)
 	}
 	return l
 }
 
-func newProjectList(projects []scanner.Project, width, height int) list.Model {
+func newProjectList(projects []scanner.Project, width, height int, byName bool) list.Model {
 	items := make([]list.Item, len(projects))
 	for i, p := range projects {
 		items[i] = projectItem{project: p}
 	}
 	l := list.New(items, newStyledDelegate(), width, height)
-	l.Title = "Projects"
+	l.Title = "Projects (recent first)"
+	if byName {
+		l.Title = "Projects (A-Z)"
+	}
 	l.SetShowStatusBar(true)
 	l.SetFilteringEnabled(true)
 	l.SetShowHelp(true)
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{projectKeys.Sort}
+	}
 	return l
 }
 
@@ -359,10 +526,41 @@ func newToolList(tools []Tool, width, height int, defaultTool string) list.Model
 	return l
 }
 
-func newConfirmList(width, height int) list.Model {
+// worktreeItem toggles a git worktree per agent cell on the confirm list.
+type worktreeItem struct {
+	on bool
+}
+
+func (i worktreeItem) Title() string {
+	if i.on {
+		return "Git worktrees: on"
+	}
+	return "Git worktrees: off"
+}
+func (i worktreeItem) Description() string {
+	return "Give each cell running a tool its own worktree and branch"
+}
+func (i worktreeItem) FilterValue() string { return i.Title() }
+
+var customizeCellsItem = confirmItem{name: "Customize cells...", desc: "Give individual terminals their own project, tool or title"}
+
+// newConfirmList builds the final actions. While editing a preset, saving
+// overwrites that preset instead of creating a new one.
+func newConfirmList(width, height int, editingPreset string, worktrees bool) list.Model {
 	items := []list.Item{
 		confirmItem{name: "Launch", desc: "Open terminals now"},
 		confirmItem{name: "Save as preset & Launch", desc: "Save this combo for quick access next time"},
+		customizeCellsItem,
+		worktreeItem{on: worktrees},
+	}
+	if editingPreset != "" {
+		items = []list.Item{
+			confirmItem{name: "Update preset & Launch", desc: "Save changes to " + editingPreset + " and open terminals"},
+			confirmItem{name: "Update preset", desc: "Save changes to " + editingPreset + " and return to presets"},
+			confirmItem{name: "Launch", desc: "Open terminals without saving changes"},
+			customizeCellsItem,
+			worktreeItem{on: worktrees},
+		}
 	}
 	l := list.New(items, newStyledDelegate(), width, height)
 	l.Title = "Ready?"
@@ -1,7 +1,12 @@
 package tui
 
 import (
+	"strings"
 	"testing"
+	"time"
+
+	"agent-t/internal/config"
+	"agent-t/internal/scanner"
 )
 
 func TestStepTitle_SingleMode(t *testing.T) {
@@ -15,33 +20,34 @@ func TestStepTitle_SingleMode(t *testing.T) {
 		{stepConfirm, "Confirm & Launch"},
 	}
 	for _, tt := range tests {
-		got := stepTitle(tt.step, false)
+		got := stepTitle(tt.step, "")
 		if got != tt.want {
-			t.Errorf("stepTitle(%d, false) = %q, want %q", tt.step, got, tt.want)
+			t.Errorf("stepTitle(%d, \"\") = %q, want %q", tt.step, got, tt.want)
 		}
 	}
 }
 
 func TestStepTitle_SplitMode(t *testing.T) {
 	tests := []struct {
-		step step
-		want string
+		step  step
+		group string
+		want  string
 	}{
-		{stepProject, "Select Top Project"},
-		{stepProjectBottom, "Select Bottom Project"},
-		{stepTool, "Select Top Tool"},
-		{stepToolBottom, "Select Bottom Tool"},
+		{stepProject, "Row 1", "Select Project for Row 1"},
+		{stepProject, "Column 3", "Select Project for Column 3"},
+		{stepTool, "Row 2", "Select Tool for Row 2"},
+		{stepLayout, "Row 1", "Select Layout"},
 	}
 	for _, tt := range tests {
-		got := stepTitle(tt.step, true)
+		got := stepTitle(tt.step, tt.group)
 		if got != tt.want {
-			t.Errorf("stepTitle(%d, true) = %q, want %q", tt.step, got, tt.want)
+			t.Errorf("stepTitle(%d, %q) = %q, want %q", tt.step, tt.group, got, tt.want)
 		}
 	}
 }
 
 func TestStepNumber_SingleMode(t *testing.T) {
-	num, total := stepNumber(stepProject, false, false)
+	num, total := stepNumber(stepProject, false, 0, 0)
 	if total != 4 {
 		t.Errorf("single mode total = %d, want 4", total)
 	}
@@ -49,34 +55,35 @@ func TestStepNumber_SingleMode(t *testing.T) {
 		t.Errorf("stepProject number = %d, want 1", num)
 	}
 
-	num, total = stepNumber(stepConfirm, false, false)
+	num, total = stepNumber(stepConfirm, false, 0, 0)
 	if num != 4 || total != 4 {
 		t.Errorf("stepConfirm = %d/%d, want 4/4", num, total)
 	}
 }
 
 func TestStepNumber_SplitMode(t *testing.T) {
-	num, total := stepNumber(stepProject, false, true)
-	if total != 7 {
-		t.Errorf("split mode total = %d, want 7", total)
+	// Three rows: mode, layout, 3 x (project, tool), confirm
+	num, total := stepNumber(stepLayout, false, 3, 0)
+	if total != 9 {
+		t.Errorf("split mode total = %d, want 9", total)
 	}
 	if num != 2 {
-		t.Errorf("stepProject in split = %d, want 2", num)
+		t.Errorf("stepLayout in split = %d, want 2", num)
 	}
 
-	num, _ = stepNumber(stepProjectBottom, false, true)
+	num, _ = stepNumber(stepProject, false, 3, 0)
 	if num != 3 {
-		t.Errorf("stepProjectBottom = %d, want 3", num)
+		t.Errorf("stepProject for row 1 = %d, want 3", num)
 	}
 
-	num, _ = stepNumber(stepToolBottom, false, true)
-	if num != 6 {
-		t.Errorf("stepToolBottom = %d, want 6", num)
+	num, _ = stepNumber(stepTool, false, 3, 2)
+	if num != 8 {
+		t.Errorf("stepTool for row 3 = %d, want 8", num)
 	}
 
-	num, total = stepNumber(stepConfirm, false, true)
-	if num != 7 || total != 7 {
-		t.Errorf("stepConfirm in split = %d/%d, want 7/7", num, total)
+	num, total = stepNumber(stepConfirm, false, 3, 2)
+	if num != 9 || total != 9 {
+		t.Errorf("stepConfirm in split = %d/%d, want 9/9", num, total)
 	}
 }
 
@@ -85,15 +92,52 @@ func TestSplitLayouts(t *testing.T) {
 		t.Fatal("SplitLayouts should not be empty")
 	}
 	for _, lay := range SplitLayouts {
-		if len(lay.RowCols) != 2 {
-			t.Errorf("SplitLayout %q has %d rows, want exactly 2", lay.Name, len(lay.RowCols))
+		if lay.Groups() < 2 {
+			t.Errorf("SplitLayout %q has %d groups, want at least 2", lay.Name, lay.Groups())
+		}
+	}
+}
+
+func TestAllLayouts_SkipsInvalidCustomLayouts(t *testing.T) {
+	cfg := &config.Config{CustomLayouts: []config.CustomLayout{
+		{Name: "negative", RowCols: []int{2, -1}},
+		{Name: "empty row", RowCols: []int{0}},
+		{Name: "none", RowCols: nil},
+		{Name: "too many", RowCols: []int{11, 11}},
+		{Name: "wide", RowCols: []int{5, 5}},
+	}}
+	for _, all := range [][]Layout{AllLayouts(cfg), AllSplitLayouts(cfg)} {
+		var custom []string
+		for _, lay := range all {
+			if lay.Custom {
+				custom = append(custom, lay.Name)
+			}
+		}
+		if len(custom) != 1 || custom[0] != "wide" {
+			t.Errorf("custom layouts offered = %v, want only wide", custom)
 		}
 	}
+
+	_, err := ResolvePreset(testProjects, cfg, nil, config.Preset{Project: "api", Layout: "0"})
+	if err == nil || !strings.Contains(err.Error(), `layout "0" not found`) {
+		t.Errorf("ResolvePreset error = %v, want the invalid layout not found", err)
+	}
+}
+
+func TestLayoutGroups(t *testing.T) {
+	rows := Layout{RowCols: []int{2, 3, 1}}
+	if rows.Groups() != 3 || rows.GroupOf(1, 2) != 1 || rows.GroupName(2) != "Row 3" {
+		t.Errorf("multi-row layout groups by row: %d %d %q", rows.Groups(), rows.GroupOf(1, 2), rows.GroupName(2))
+	}
+	cols := Layout{RowCols: []int{4}}
+	if cols.Groups() != 4 || cols.GroupOf(0, 2) != 2 || cols.GroupName(0) != "Column 1" {
+		t.Errorf("single-row layout groups by column: %d %d %q", cols.Groups(), cols.GroupOf(0, 2), cols.GroupName(0))
+	}
 }
 
 func TestModeItem(t *testing.T) {
 	single := modeItem{name: "Single Project", desc: "All terminals in one project", split: false}
-	split := modeItem{name: "Split Workspace", desc: "Top row = project A, bottom row = project B", split: true}
+	split := modeItem{name: "Split Workspace", desc: "Each row (or column) gets its own project and tool", split: true}
 
 	if single.Title() != "Single Project" {
 		t.Errorf("single Title() = %q", single.Title())
@@ -108,3 +152,62 @@ func TestModeItem(t *testing.T) {
 		t.Error("split mode should be split")
 	}
 }
+
+func TestProjectItemDescription(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		project scanner.Project
+		want    string
+	}{
+		{
+			scanner.Project{Path: "/srv/api"},
+			"/srv/api",
+		},
+		{
+			scanner.Project{Path: "/home/me/code/api", Root: "/home/me/code"},
+			"~/code/api · from ~/code",
+		},
+		{
+			scanner.Project{
+				Root:     "/home/me/code",
+				Language: "Go",
+				Git:      &scanner.GitInfo{Branch: "main", Ahead: 2, Behind: 1, Dirty: 3, LastCommit: now.Add(-3 * time.Hour)},
+			},
+			"Go · main ↑2 ↓1 · 3 changed · 3h ago · from ~/code",
+		},
+		{
+			scanner.Project{Git: &scanner.GitInfo{Branch: "feature"}},
+			"feature · clean",
+		},
+		{
+			scanner.Project{Language: "Go", ConfigFile: "/srv/api/.agent-t.yaml"},
+			"Go · .agent-t.yaml",
+		},
+	}
+	for _, tt := range tests {
+		if got := projectSummary(tt.project, now); got != tt.want {
+			t.Errorf("projectSummary(%+v) = %q, want %q", tt.project, got, tt.want)
+		}
+	}
+}
+
+func TestAgo(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{5 * time.Minute, "5m ago"},
+		{26 * time.Hour, "1d ago"},
+		{70 * 24 * time.Hour, "2mo ago"},
+		{800 * 24 * time.Hour, "2y ago"},
+	}
+	for _, tt := range tests {
+		if got := ago(now.Add(-tt.d), now); got != tt.want {
+			t.Errorf("ago(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
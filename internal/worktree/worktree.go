@@ -0,0 +1,268 @@
+// Package worktree gives agent terminals their own git worktree and branch,
+// and keeps a record of them so they can be cleaned up later.
+package worktree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"agent-t/internal/fileutil"
+)
+
+// Record is a worktree created by agent-t.
+type Record struct {
+	Repo    string    `json:"repo"`   // top level of the main checkout
+	Path    string    `json:"path"`   // where the worktree is checked out
+	Branch  string    `json:"branch"` // branch created for it
+	Base    string    `json:"base"`   // branch (or commit, when detached) it was created from
+	Commit  string    `json:"commit"` // commit Base pointed at
+	Created time.Time `json:"created"`
+}
+
+// Spec describes the worktree for one cell before it is created.
+type Spec struct {
+	Repo   string // top level of the repository
+	Path   string // where the worktree is checked out
+	Branch string
+	Dir    string // the cell's directory inside the worktree
+}
+
+// Stamp formats t the way worktree and branch names include it, so the
+// worktrees of one launch share a name and sort by time.
+func Stamp(t time.Time) string {
+	return t.Format("20060102-150405")
+}
+
+// PlanCell works out the worktree for cell n (zero-based) of a launch
+// that would run in dir. dir may be a subdirectory of its repository; the
+// cell then starts in the same subdirectory of the worktree.
+func PlanCell(dir, root, stamp string, n int) (Spec, error) {
+	repo, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return Spec{}, fmt.Errorf("%s is not in a git repository", dir)
+	}
+	// git resolves symlinks in the top level, so dir must be resolved too
+	// for the two to share a prefix
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return Spec{}, err
+	}
+	rel, err := filepath.Rel(repo, real)
+	if err != nil {
+		return Spec{}, err
+	}
+	name := fmt.Sprintf("%s-%s-%s", filepath.Base(repo), stamp, strconv.Itoa(n+1))
+	path := filepath.Join(root, name)
+	return Spec{
+		Repo:   repo,
+		Path:   path,
+		Branch: "agent-t/" + name,
+		Dir:    filepath.Join(path, rel),
+	}, nil
+}
+
+// Create checks out a new branch for s from the repository's current HEAD.
+func Create(s Spec) (Record, error) {
+	base, err := git(s.Repo, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return Record{}, err
+	}
+	commit, err := git(s.Repo, "rev-parse", "HEAD")
+	if err != nil {
+		return Record{}, err
+	}
+	if base == "HEAD" {
+		base = commit
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return Record{}, err
+	}
+	if _, err := git(s.Repo, "worktree", "add", "-b", s.Branch, s.Path, commit); err != nil {
+		return Record{}, err
+	}
+	return Record{
+		Repo:    s.Repo,
+		Path:    s.Path,
+		Branch:  s.Branch,
+		Base:    base,
+		Commit:  commit,
+		Created: time.Now(),
+	}, nil
+}
+
+// Status is what cleanup makes of a recorded worktree.
+type Status int
+
+const (
+	Missing  Status = iota // directory is gone
+	Unused                 // clean, no commits of its own
+	Merged                 // clean, all its commits are in Base
+	Dirty                  // has uncommitted changes
+	Unmerged               // has commits that are not in Base
+)
+
+func (s Status) String() string {
+	switch s {
+	case Missing:
+		return "missing"
+	case Unused:
+		return "unused"
+	case Merged:
+		return "merged"
+	case Dirty:
+		return "dirty"
+	case Unmerged:
+		return "unmerged"
+	default:
+		return "unknown"
+	}
+}
+
+// Removable reports whether cleanup removes a worktree in this state
+// without --force.
+func (s Status) Removable() bool {
+	return s == Missing || s == Unused || s == Merged
+}
+
+// Check inspects r's worktree and branch.
+func Check(r Record) (Status, error) {
+	if _, err := os.Stat(r.Path); errors.Is(err, os.ErrNotExist) {
+		return Missing, nil
+	}
+	changes, err := git(r.Path, "status", "--porcelain")
+	if err != nil {
+		return 0, err
+	}
+	if changes != "" {
+		return Dirty, nil
+	}
+	tip, err := git(r.Repo, "rev-parse", r.Branch)
+	if err != nil {
+		return 0, err
+	}
+	if tip == r.Commit {
+		return Unused, nil
+	}
+	if _, err := git(r.Repo, "merge-base", "--is-ancestor", r.Branch, r.Base); err == nil {
+		return Merged, nil
+	}
+	return Unmerged, nil
+}
+
+// Remove deletes r's worktree and branch. force also discards uncommitted
+// changes and unmerged commits.
+func Remove(r Record, force bool) error {
+	if _, err := os.Stat(r.Path); err == nil {
+		args := []string{"worktree", "remove", r.Path}
+		if force {
+			args = []string{"worktree", "remove", "--force", r.Path}
+		}
+		if _, err := git(r.Repo, args...); err != nil {
+			return err
+		}
+	} else if _, err := git(r.Repo, "worktree", "prune"); err != nil {
+		return err
+	}
+
+	// The branch may already have been deleted by hand
+	if _, err := git(r.Repo, "rev-parse", "--verify", "--quiet", "refs/heads/"+r.Branch); err != nil {
+		return nil
+	}
+	del := "-d"
+	if force {
+		del = "-D"
+	}
+	_, err := git(r.Repo, "branch", del, r.Branch)
+	return err
+}
+
+// LoadRecords reads the records kept at path. A missing file means no
+// records.
+func LoadRecords(path string) ([]Record, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseRecords(path, data)
+}
+
+func parseRecords(path string, data []byte) ([]Record, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var records []Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return records, nil
+}
+
+// AddRecords appends records to the ones kept at path.
+func AddRecords(path string, records ...Record) error {
+	return updateRecords(path, func(existing []Record) []Record {
+		return append(existing, records...)
+	})
+}
+
+// RemoveRecords drops the records of the worktrees at the paths of records
+// from the ones kept at path.
+func RemoveRecords(path string, records ...Record) error {
+	removed := make(map[string]bool, len(records))
+	for _, r := range records {
+		removed[r.Path] = true
+	}
+	return updateRecords(path, func(existing []Record) []Record {
+		kept := []Record{}
+		for _, r := range existing {
+			if !removed[r.Path] {
+				kept = append(kept, r)
+			}
+		}
+		return kept
+	})
+}
+
+// updateRecords replaces the records kept at path with what update makes
+// of them, under a lock, so launches and cleanups running at the same time
+// keep each other's changes.
+func updateRecords(path string, update func([]Record) []Record) error {
+	return fileutil.Update(path, func(old []byte) ([]byte, error) {
+		records, err := parseRecords(path, old)
+		if err != nil {
+			return nil, err
+		}
+		records = update(records)
+		if records == nil {
+			records = []Record{}
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	})
+}
+
+// git runs git in dir and returns its trimmed output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,239 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testRepo creates a git repository with one commit and a subdirectory.
+func testRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := filepath.Join(t.TempDir(), "api")
+	if err := os.MkdirAll(filepath.Join(repo, "cmd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "README"), []byte("hi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "init", "-q", "-b", "main")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+	return repo
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func createCell(t *testing.T, repo, root string, n int) Record {
+	t.Helper()
+	spec, err := PlanCell(repo, root, "20260101-120000", n)
+	if err != nil {
+		t.Fatalf("PlanCell: %v", err)
+	}
+	rec, err := Create(spec)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return rec
+}
+
+func TestPlanCell(t *testing.T) {
+	repo := testRepo(t)
+	root := t.TempDir()
+
+	spec, err := PlanCell(filepath.Join(repo, "cmd"), root, "20260101-120000", 1)
+	if err != nil {
+		t.Fatalf("PlanCell: %v", err)
+	}
+	if spec.Branch != "agent-t/api-20260101-120000-2" {
+		t.Errorf("Branch = %q", spec.Branch)
+	}
+	if spec.Path != filepath.Join(root, "api-20260101-120000-2") {
+		t.Errorf("Path = %q", spec.Path)
+	}
+	if spec.Dir != filepath.Join(spec.Path, "cmd") {
+		t.Errorf("Dir = %q, want the same subdirectory inside the worktree", spec.Dir)
+	}
+
+	if _, err := PlanCell(t.TempDir(), root, "x", 0); err == nil {
+		t.Error("PlanCell should fail outside a git repository")
+	}
+}
+
+func TestPlanCell_Symlink(t *testing.T) {
+	repo := testRepo(t)
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(repo, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	root := t.TempDir()
+
+	spec, err := PlanCell(filepath.Join(link, "cmd"), root, "20260101-120000", 0)
+	if err != nil {
+		t.Fatalf("PlanCell: %v", err)
+	}
+	if spec.Dir != filepath.Join(spec.Path, "cmd") {
+		t.Errorf("Dir = %q, want the subdirectory inside the worktree", spec.Dir)
+	}
+}
+
+func TestCreateAndCheck(t *testing.T) {
+	repo := testRepo(t)
+	root := t.TempDir()
+
+	unused := createCell(t, repo, root, 0)
+	if unused.Base != "main" || unused.Commit == "" {
+		t.Errorf("record = %+v, want base main and a commit", unused)
+	}
+	if got := runGit(t, unused.Path, "branch", "--show-current"); got != unused.Branch {
+		t.Errorf("worktree is on %q, want %q", got, unused.Branch)
+	}
+
+	dirty := createCell(t, repo, root, 1)
+	os.WriteFile(filepath.Join(dirty.Path, "scratch"), []byte("x"), 0o644)
+
+	unmerged := createCell(t, repo, root, 2)
+	runGit(t, unmerged.Path, "commit", "-q", "--allow-empty", "-m", "work")
+
+	merged := createCell(t, repo, root, 3)
+	runGit(t, merged.Path, "commit", "-q", "--allow-empty", "-m", "done")
+	runGit(t, repo, "merge", "-q", "--ff-only", merged.Branch)
+
+	missing := createCell(t, repo, root, 4)
+	os.RemoveAll(missing.Path)
+
+	tests := []struct {
+		rec  Record
+		want Status
+	}{
+		{unused, Unused},
+		{dirty, Dirty},
+		{unmerged, Unmerged},
+		{merged, Merged},
+		{missing, Missing},
+	}
+	for _, tt := range tests {
+		got, err := Check(tt.rec)
+		if err != nil {
+			t.Errorf("Check(%s): %v", tt.rec.Branch, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Check(%s) = %s, want %s", tt.rec.Branch, got, tt.want)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	repo := testRepo(t)
+	root := t.TempDir()
+
+	rec := createCell(t, repo, root, 0)
+	if err := Remove(rec, false); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(rec.Path); !os.IsNotExist(err) {
+		t.Error("worktree directory should be gone")
+	}
+	if out := runGit(t, repo, "branch", "--list", rec.Branch); out != "" {
+		t.Errorf("branch should be deleted, got %q", out)
+	}
+
+	// Unmerged work is only discarded with force
+	rec = createCell(t, repo, root, 1)
+	runGit(t, rec.Path, "commit", "-q", "--allow-empty", "-m", "work")
+	if err := Remove(rec, false); err == nil {
+		t.Error("Remove without force should refuse to delete an unmerged branch")
+	}
+	if err := Remove(rec, true); err != nil {
+		t.Fatalf("Remove with force: %v", err)
+	}
+	if out := runGit(t, repo, "branch", "--list", rec.Branch); out != "" {
+		t.Errorf("branch should be deleted, got %q", out)
+	}
+
+	// A worktree deleted by hand is pruned
+	rec = createCell(t, repo, root, 2)
+	os.RemoveAll(rec.Path)
+	if err := Remove(rec, false); err != nil {
+		t.Fatalf("Remove of a missing worktree: %v", err)
+	}
+	if out := runGit(t, repo, "worktree", "list"); strings.Contains(out, rec.Path) {
+		t.Errorf("missing worktree should be pruned:\n%s", out)
+	}
+}
+
+func TestRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "worktrees.json")
+
+	records, err := LoadRecords(path)
+	if err != nil || records != nil {
+		t.Fatalf("LoadRecords of a missing file = %v, %v", records, err)
+	}
+
+	a := Record{Repo: "/r", Path: "/w/a", Branch: "agent-t/a", Created: time.Unix(0, 0).UTC()}
+	b := Record{Repo: "/r", Path: "/w/b", Branch: "agent-t/b", Created: time.Unix(0, 0).UTC()}
+	if err := AddRecords(path, a); err != nil {
+		t.Fatalf("AddRecords: %v", err)
+	}
+	if err := AddRecords(path, b); err != nil {
+		t.Fatalf("AddRecords: %v", err)
+	}
+	records, err = LoadRecords(path)
+	if err != nil {
+		t.Fatalf("LoadRecords: %v", err)
+	}
+	if len(records) != 2 || records[0] != a || records[1] != b {
+		t.Errorf("records = %+v", records)
+	}
+}
+
+func TestRecords_RemoveAndParallel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worktrees.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := Record{Repo: "/r", Path: fmt.Sprintf("/w/%d", i), Created: time.Unix(0, 0).UTC()}
+			if err := AddRecords(path, r); err != nil {
+				t.Errorf("AddRecords: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	records, err := LoadRecords(path)
+	if err != nil || len(records) != 10 {
+		t.Fatalf("concurrent AddRecords kept %d records, want 10 (%v)", len(records), err)
+	}
+
+	if err := RemoveRecords(path, Record{Path: "/w/3"}, Record{Path: "/w/7"}); err != nil {
+		t.Fatalf("RemoveRecords: %v", err)
+	}
+	records, _ = LoadRecords(path)
+	if len(records) != 8 {
+		t.Errorf("records after removing 2 = %d, want 8", len(records))
+	}
+	for _, r := range records {
+		if r.Path == "/w/3" || r.Path == "/w/7" {
+			t.Errorf("%s should be removed", r.Path)
+		}
+	}
+}
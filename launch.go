@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"agent-t/internal/config"
+	"agent-t/internal/launcher"
+	"agent-t/internal/tui"
+)
+
+const launchUsage = `Usage:
+  agent-t launch --preset NAME [flags]
+  agent-t launch --project NAME --layout ROWCOLS [--tool NAME] [flags]
+  agent-t launch --row PROJECT[:TOOL] --row PROJECT[:TOOL]... --layout ROWCOLS [flags]
+
+Launches a workspace without the interactive wizard.
+
+Flags:
+`
+
+// runLaunch resolves a preset or an ad-hoc workspace from flags and
+// launches it without a TTY.
+func runLaunch(args []string) {
+	fs := flag.NewFlagSet("agent-t launch", flag.ExitOnError)
+	presetName := fs.String("preset", "", "name of a saved preset")
+	project := fs.String("project", "", "project to open")
+	layout := fs.String("layout", "", "layout as columns per row, e.g. 3,3")
+	tool := fs.String("tool", "", "tool name, e.g. \"Claude Code\" (default: none)")
+	var rows rowFlags
+	fs.Var(&rows, "row", "`PROJECT[:TOOL]` for the next row, or column of a single-row layout (split workspace, repeatable)")
+	worktrees := fs.Bool("worktrees", false, "give each cell running a tool its own git worktree and branch")
+	lf := addLaunchFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), launchUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		fatalf("Error: unexpected argument %q", fs.Arg(0))
+	}
+
+	cfg, projects, _ := loadWorkspace()
+	b := lf.resolveBackend(cfg)
+
+	var preset config.Preset
+	switch {
+	case *presetName != "":
+		if *project != "" || *layout != "" || *tool != "" || len(rows) > 0 {
+			fatalf("Error: --preset cannot be combined with --project, --row, --layout or --tool")
+		}
+		p, ok := cfg.FindPreset(*presetName)
+		if !ok {
+			fatalf("Error: no preset named %q", *presetName)
+		}
+		preset = p
+	case *project != "" || len(rows) > 0:
+		if *layout == "" {
+			fatalf("Error: --layout is required with --project or --row")
+		}
+		if *project != "" && len(rows) > 0 {
+			fatalf("Error: --project cannot be combined with --row")
+		}
+		preset = config.Preset{
+			Project: *project,
+			Layout:  *layout,
+			Tool:    *tool,
+			Rows:    rows,
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *worktrees {
+		preset.Worktrees = true
+	}
+
+	final, err := tui.ResolvePreset(projects, cfg, loadTrust(), preset)
+	if err != nil {
+		if preset.Name != "" {
+			fatalf("Error: preset %q: %v", preset.Name, err)
+		}
+		fatalf("Error: %v", err)
+	}
+
+	launch(final, b, lf)
+}
+
+// launch turns the selections of a finished wizard (or a resolved preset)
+// into launcher options, then prints or launches them as lf asks.
+func launch(final tui.Model, b launcher.Backend, lf *launchFlags) {
+	layout := final.SelectedLayout()
+
+	var where string
+	if final.IsSplitMode() {
+		parts := make([]string, len(final.Rows()))
+		for i, r := range final.Rows() {
+			parts[i] = fmt.Sprintf("%s: %s", strings.ToLower(layout.GroupName(i)), r.Project.Name)
+		}
+		where = "— " + strings.Join(parts, ", ")
+	} else {
+		where = "in " + final.SelectedProject().Name
+	}
+
+	// Every cell is resolved by the model, so per-cell overrides and the
+	// per-row selections reach the launcher the same way
+	var cells []launcher.CellSpec
+	for _, c := range final.Cells() {
+		cells = append(cells, launcher.CellSpec{
+			Dir:     c.Project.Path,
+			Command: c.Tool.Command,
+			Title:   c.Title,
+			Env:     c.Env,
+		})
+	}
+
+	if final.Worktrees() {
+		var err error
+		cells, err = checkoutWorktrees(final.Config(), cells, !lf.printsOnly())
+		if err != nil {
+			fatalf("Error creating worktrees: %v", err)
+		}
+	}
+
+	setup := setupSteps(final.Cells(), cells)
+
+	opts := launcher.Options{
+		RowCols: layout.RowCols,
+		Cells:   cells,
+		Backend: b.Name(),
+	}
+
+	if *lf.printLayout {
+		kdl, err := launcher.ZellijLayout(launcher.NewPlan(b, opts))
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		fmt.Print(kdl)
+		return
+	}
+
+	if lf.printsOnly() {
+		plan := launcher.NewPlan(b, opts)
+		fmt.Printf("Dry run: %d terminals (%s) %s\n\n", layout.TotalTerminals(), layout.Desc, where)
+		fmt.Print(plan.Describe(b.Name()))
+		if len(setup) > 0 {
+			fmt.Println("\nSetup:")
+			for _, st := range setup {
+				fmt.Printf("  (cd %s && %s)\n", st.Dir, st.Command)
+			}
+		}
+		if *lf.printScript {
+			script, err := b.Script(plan)
+			if err != nil {
+				fatalf("Error: %v", err)
+			}
+			fmt.Printf("\n--- %s script ---\n%s\n", b.Name(), strings.TrimRight(script, "\n"))
+		}
+		return
+	}
+
+	for _, st := range setup {
+		if err := st.run(); err != nil {
+			fatalf("Error: setup %q in %s: %v", st.Command, st.Dir, err)
+		}
+	}
+
+	fmt.Printf("Launching %d terminals (%s) %s...\n", layout.TotalTerminals(), layout.Desc, where)
+	if err := launcher.Launch(opts); err != nil {
+		fatalf("Error launching: %v", err)
+	}
+	recordLaunch(final)
+}
+
+// setupStep is a setup command from a project's config file.
+type setupStep struct {
+	Dir     string
+	Command string
+}
+
+// setupSteps lists the setup commands of the projects the cells open, once
+// for each directory they start in. specs are the launcher's cells, whose
+// directories are in worktrees when those are used.
+func setupSteps(cells []tui.Cell, specs []launcher.CellSpec) []setupStep {
+	var steps []setupStep
+	seen := make(map[string]bool)
+	for i, c := range cells {
+		dir := specs[i].Dir
+		if len(c.Setup) == 0 || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		for _, cmd := range c.Setup {
+			steps = append(steps, setupStep{Dir: dir, Command: cmd})
+		}
+	}
+	return steps
+}
+
+func (st setupStep) run() error {
+	fmt.Printf("Setting up %s: %s\n", st.Dir, st.Command)
+	cmd := exec.Command("sh", "-c", st.Command)
+	cmd.Dir = st.Dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// rowFlags collects repeated --row PROJECT[:TOOL] flags into the row
+// assignments of a split workspace.
+type rowFlags []config.PresetRow
+
+func (r *rowFlags) String() string {
+	parts := make([]string, len(*r))
+	for i, row := range *r {
+		parts[i] = row.Project
+		if row.Tool != "" {
+			parts[i] += ":" + row.Tool
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (r *rowFlags) Set(value string) error {
+	project, tool, _ := strings.Cut(value, ":")
+	if project == "" {
+		return fmt.Errorf("missing project in %q", value)
+	}
+	*r = append(*r, config.PresetRow{Project: project, Tool: tool})
+	return nil
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"agent-t/internal/config"
 	"agent-t/internal/launcher"
@@ -13,86 +15,189 @@ import (
 )
 
 func main() {
+	args, configFile := extractConfigFlag(os.Args[1:])
+	if configFile != "" {
+		config.SetPath(configFile)
+	}
+	if len(args) > 0 {
+		switch args[0] {
+		case "launch":
+			runLaunch(args[1:])
+			return
+		case "preset":
+			runPreset(args[1:])
+			return
+		case "cleanup":
+			runCleanup(args[1:])
+			return
+		case "last":
+			runLast(args[1:])
+			return
+		case "trust":
+			runTrust(args[1:])
+			return
+		case "config":
+			runConfig(args[1:])
+			return
+		}
+	}
+	runWizard(args)
+}
+
+// extractConfigFlag removes --config PATH (or --config=PATH) from args, so
+// it can be given anywhere on the command line, before or after the
+// subcommand. Arguments after "--" are left alone.
+func extractConfigFlag(args []string) (rest []string, path string) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--":
+			return append(rest, args[i:]...), path
+		case a == "--config" || a == "-config":
+			if i+1 >= len(args) {
+				fatalf("Error: %s needs a path", a)
+			}
+			path = args[i+1]
+			i++
+		case strings.HasPrefix(a, "--config=") || strings.HasPrefix(a, "-config="):
+			_, path, _ = strings.Cut(a, "=")
+		default:
+			rest = append(rest, a)
+		}
+	}
+	return rest, path
+}
+
+// runWizard walks the user through the interactive TUI, then launches.
+func runWizard(args []string) {
+	fs := flag.NewFlagSet("agent-t", flag.ExitOnError)
+	lf := addLaunchFlags(fs)
+	fs.Parse(args)
+
+	// The wizard reads the git state and languages shown in the project
+	// list in the background
+	cfg, projects, cwd := loadWorkspace()
+	b := lf.resolveBackend(cfg)
+
+	m := tui.NewModel(projects, cfg, cwd, loadHistory(), loadTrust())
+	progOpts := []tea.ProgramOption{tea.WithAltScreen()}
+	if lf.printsOnly() {
+		// Keep stdout clean for the output so it can be redirected to a file
+		progOpts = append(progOpts, tea.WithOutput(os.Stderr))
+	}
+	p := tea.NewProgram(m, progOpts...)
+
+	result, err := p.Run()
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+
+	final := result.(tui.Model)
+
+	// Save config if presets were added, edited or deleted, even when the
+	// wizard was cancelled afterwards
+	if final.ConfigChanged() {
+		if err := config.Save(final.Config()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not save config: %v\n", err)
+		}
+	}
+	if final.TrustChanged() {
+		if err := saveTrust(final.TrustStore()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not save trusted configs: %v\n", err)
+		}
+	}
+
+	if final.Cancelled() {
+		os.Exit(0)
+	}
+
+	launch(final, b, lf)
+}
+
+// loadWorkspace loads the config and scans the working directory and the
+// configured project roots for projects, exiting when the config cannot be
+// loaded or no projects are found.
+func loadWorkspace() (*config.Config, []scanner.Project, string) {
 	cwd, err := os.Getwd()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		fatalf("Error: %v", err)
 	}
 
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-		os.Exit(1)
+		fatalf("Error loading config: %v", err)
 	}
 
-	projects, err := scanner.Scan(cwd)
+	projects, roots, err := findProjects(cfg, cwd)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error scanning %s: %v\n", cwd, err)
-		os.Exit(1)
+		// A missing root is not fatal as long as the others have projects
+		if len(projects) == 0 {
+			fatalf("Error: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if len(roots) == 0 {
+		fatalf("No project roots to scan: scan_cwd is false and project_roots matches no directories")
 	}
 
 	if len(projects) == 0 {
-		fmt.Fprintf(os.Stderr, "No project folders found in %s\n", cwd)
-		os.Exit(1)
+		fatalf("No project folders found in %s", strings.Join(roots, ", "))
 	}
 
-	m := tui.NewModel(projects, cfg, cwd)
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	return cfg, projects, cwd
+}
 
-	result, err := p.Run()
+// findProjects scans the working directory, unless scan_cwd is false, and
+// the configured project roots. It returns the roots scanned, and the
+// projects found even when some roots could not be scanned.
+func findProjects(cfg *config.Config, cwd string) ([]scanner.Project, []string, error) {
+	roots, err := cfg.Roots()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return nil, nil, err
+	}
+	if cfg.ScansCwd() {
+		roots = append([]string{cwd}, roots...)
 	}
+	projects, err := scanner.ScanRoots(roots, scanner.Options{MaxDepth: cfg.ScanDepth})
+	return projects, roots, err
+}
 
-	final := result.(tui.Model)
+// launchFlags are the output flags shared by the wizard and `agent-t launch`.
+type launchFlags struct {
+	backend     *string
+	printLayout *bool
+	dryRun      *bool
+	printScript *bool
+}
 
-	if final.Cancelled() {
-		os.Exit(0)
+func addLaunchFlags(fs *flag.FlagSet) *launchFlags {
+	return &launchFlags{
+		backend:     fs.String("backend", "", "terminal backend: "+strings.Join(launcher.BackendNames(), ", ")),
+		printLayout: fs.Bool("print-layout", false, "print the workspace as a Zellij KDL layout instead of launching"),
+		dryRun:      fs.Bool("dry-run", false, "print the resolved plan instead of launching"),
+		printScript: fs.Bool("print-script", false, "with --dry-run, also print the backend script (implies --dry-run)"),
 	}
+}
 
-	// Save config if presets were added
-	if final.ConfigChanged() {
-		if err := config.Save(final.Config()); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: could not save config: %v\n", err)
-		}
-	}
+// printsOnly reports whether the run prints output instead of launching.
+func (lf *launchFlags) printsOnly() bool {
+	return *lf.printLayout || *lf.dryRun || *lf.printScript
+}
 
-	// Launch terminals
-	layout := final.SelectedLayout()
-	numRows := len(layout.RowCols)
-
-	// Build per-row project dirs and commands
-	var projectDirs []string
-	var commands []string
-
-	if final.IsSplitMode() {
-		topPath := final.SelectedProject().Path
-		bottomPath := final.SelectedBottomProject().Path
-		projectDirs = []string{topPath, bottomPath}
-		commands = []string{final.SelectedTool().Command, final.SelectedToolBottom().Command}
-		fmt.Printf("Launching %d terminals (%s) — top: %s, bottom: %s...\n",
-			layout.TotalTerminals(), layout.Desc,
-			final.SelectedProject().Name, final.SelectedBottomProject().Name)
-	} else {
-		projectDirs = make([]string, numRows)
-		commands = make([]string, numRows)
-		for i := 0; i < numRows; i++ {
-			projectDirs[i] = final.SelectedProject().Path
-			commands[i] = final.SelectedTool().Command
-		}
-		fmt.Printf("Launching %d terminals (%s) in %s...\n",
-			layout.TotalTerminals(), layout.Desc,
-			final.SelectedProject().Name)
+// resolveBackend picks the backend; --backend wins over the config file.
+func (lf *launchFlags) resolveBackend(cfg *config.Config) launcher.Backend {
+	name := cfg.Backend
+	if *lf.backend != "" {
+		name = *lf.backend
 	}
-
-	err = launcher.Launch(launcher.Options{
-		ProjectDirs: projectDirs,
-		RowCols:     layout.RowCols,
-		Commands:    commands,
-	})
+	b, err := launcher.NewBackend(name)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error launching: %v\n", err)
-		os.Exit(1)
+		fatalf("Error: %v", err)
 	}
+	return b
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
 }
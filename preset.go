@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"agent-t/internal/config"
+	"agent-t/internal/scanner"
+	"agent-t/internal/tui"
+
+	"gopkg.in/yaml.v3"
+)
+
+const presetUsage = `Usage:
+  agent-t preset list [--json]
+  agent-t preset show NAME [--json]
+  agent-t preset add NAME --project NAME --layout ROWCOLS [--tool NAME]
+                          [--worktrees] [--no-check]
+  agent-t preset add NAME --row PROJECT[:TOOL]... --layout ROWCOLS
+                          [--worktrees] [--no-check]
+  agent-t preset rename OLD NEW
+  agent-t preset rm NAME
+  agent-t preset export [NAME...] [--json]
+
+Manages the presets saved in the config file.
+`
+
+// runPreset dispatches the `agent-t preset` subcommands.
+func runPreset(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, presetUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		presetList(args[1:])
+	case "show":
+		presetShow(args[1:])
+	case "add":
+		presetAdd(args[1:])
+	case "rename", "mv":
+		presetRename(args[1:])
+	case "rm", "delete":
+		presetDelete(args[1:])
+	case "export":
+		presetExport(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(presetUsage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown preset command %q\n\n%s", args[0], presetUsage)
+		os.Exit(2)
+	}
+}
+
+func presetList(args []string) {
+	fs := flag.NewFlagSet("agent-t preset list", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print JSON")
+	if rest := parseInterspersed(fs, args); len(rest) > 0 {
+		fatalf("Error: unexpected argument %q", rest[0])
+	}
+
+	cfg := loadConfig()
+	if *asJSON {
+		printJSON(presetsOrEmpty(cfg.Presets))
+		return
+	}
+	if len(cfg.Presets) == 0 {
+		fmt.Println("No presets saved.")
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tWORKSPACE")
+	for _, p := range cfg.Presets {
+		fmt.Fprintf(tw, "%s\t%s\n", p.Name, p.Summary())
+	}
+	tw.Flush()
+}
+
+func presetShow(args []string) {
+	fs := flag.NewFlagSet("agent-t preset show", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest := parseInterspersed(fs, args)
+	if len(rest) != 1 {
+		fatalf("Usage: agent-t preset show NAME [--json]")
+	}
+
+	p, ok := loadConfig().FindPreset(rest[0])
+	if !ok {
+		fatalf("Error: no preset named %q", rest[0])
+	}
+	if *asJSON {
+		printJSON(p)
+		return
+	}
+	printYAML(p)
+}
+
+func presetAdd(args []string) {
+	fs := flag.NewFlagSet("agent-t preset add", flag.ExitOnError)
+	project := fs.String("project", "", "project to open")
+	layout := fs.String("layout", "", "layout as columns per row, e.g. 3,3")
+	tool := fs.String("tool", "", "tool name (default: none)")
+	var rows rowFlags
+	fs.Var(&rows, "row", "`PROJECT[:TOOL]` for the next row, or column of a single-row layout (split workspace, repeatable)")
+	worktrees := fs.Bool("worktrees", false, "give each cell running a tool its own git worktree and branch")
+	noCheck := fs.Bool("no-check", false, "skip checking the project, layout and tool exist")
+	rest := parseInterspersed(fs, args)
+	if len(rest) != 1 || (*project == "") == (len(rows) == 0) || *layout == "" {
+		fatalf("Usage: agent-t preset add NAME (--project NAME [--tool NAME] | --row PROJECT[:TOOL]...) --layout ROWCOLS")
+	}
+
+	p := config.Preset{
+		Name:      rest[0],
+		Project:   *project,
+		Layout:    *layout,
+		Tool:      *tool,
+		Rows:      rows,
+		Worktrees: *worktrees,
+	}
+
+	var cfg *config.Config
+	if *noCheck {
+		cfg = loadConfig()
+	} else {
+		var projects []scanner.Project
+		cfg, projects, _ = loadWorkspace()
+		if _, err := tui.ResolvePreset(projects, cfg, loadTrust(), p); err != nil {
+			fatalf("Error: %v (use --no-check to save it anyway)", err)
+		}
+	}
+
+	if err := cfg.AddPreset(p); err != nil {
+		fatalf("Error: %v", err)
+	}
+	saveConfig(cfg)
+	fmt.Printf("Added preset %q: %s\n", p.Name, p.Summary())
+}
+
+func presetRename(args []string) {
+	if len(args) != 2 {
+		fatalf("Usage: agent-t preset rename OLD NEW")
+	}
+	cfg := loadConfig()
+	if err := cfg.RenamePreset(args[0], args[1]); err != nil {
+		fatalf("Error: %v", err)
+	}
+	saveConfig(cfg)
+	fmt.Printf("Renamed preset %q to %q\n", args[0], args[1])
+}
+
+func presetDelete(args []string) {
+	if len(args) != 1 {
+		fatalf("Usage: agent-t preset rm NAME")
+	}
+	cfg := loadConfig()
+	if err := cfg.DeletePreset(args[0]); err != nil {
+		fatalf("Error: %v", err)
+	}
+	saveConfig(cfg)
+	fmt.Printf("Deleted preset %q\n", args[0])
+}
+
+// presetExport prints presets in the config file format, so the output can
+// be pasted into another config.
+func presetExport(args []string) {
+	fs := flag.NewFlagSet("agent-t preset export", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print JSON")
+	names := parseInterspersed(fs, args)
+
+	cfg := loadConfig()
+	presets := cfg.Presets
+	if len(names) > 0 {
+		presets = nil
+		for _, name := range names {
+			p, ok := cfg.FindPreset(name)
+			if !ok {
+				fatalf("Error: no preset named %q", name)
+			}
+			presets = append(presets, p)
+		}
+	}
+
+	if *asJSON {
+		printJSON(presetsOrEmpty(presets))
+		return
+	}
+	printYAML(struct {
+		Presets []config.Preset `yaml:"presets"`
+	}{presetsOrEmpty(presets)})
+}
+
+// parseInterspersed parses fs from args, allowing flags after positional
+// arguments, and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var rest []string
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			return rest
+		}
+		rest = append(rest, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func loadConfig() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		fatalf("Error loading config: %v", err)
+	}
+	return cfg
+}
+
+func saveConfig(cfg *config.Config) {
+	if err := config.Save(cfg); err != nil {
+		fatalf("Error saving config: %v", err)
+	}
+}
+
+// presetsOrEmpty keeps an empty list from being printed as null.
+func presetsOrEmpty(presets []config.Preset) []config.Preset {
+	if presets == nil {
+		return []config.Preset{}
+	}
+	return presets
+}
+
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fatalf("Error: %v", err)
+	}
+}
+
+func printYAML(v any) {
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		fatalf("Error: %v", err)
+	}
+	enc.Close()
+}
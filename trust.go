@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"agent-t/internal/config"
+	"agent-t/internal/trust"
+)
+
+const trustUsage = `Usage:
+  agent-t trust list
+  agent-t trust revoke PATH...
+
+A repository's .agent-t.yaml can run commands, so agent-t asks before
+using one for the first time and again whenever it changes. These
+commands show and withdraw the approvals given. PATH is the config file
+or the project directory containing it.
+`
+
+func trustPath() (string, error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "trusted.json"), nil
+}
+
+// loadTrust reads the approved repository config files, exiting when they
+// cannot be read.
+func loadTrust() *trust.Store {
+	path, err := trustPath()
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+	store, err := trust.Load(path)
+	if err != nil {
+		fatalf("Error loading trusted configs: %v", err)
+	}
+	return store
+}
+
+func saveTrust(store *trust.Store) error {
+	path, err := trustPath()
+	if err != nil {
+		return err
+	}
+	return store.Save(path)
+}
+
+func runTrust(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, trustUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		trustList(args[1:])
+	case "revoke", "rm":
+		trustRevoke(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(trustUsage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown trust command %q\n\n%s", args[0], trustUsage)
+		os.Exit(2)
+	}
+}
+
+// trustList prints the approved config files and whether they changed
+// since.
+func trustList(args []string) {
+	fs := flag.NewFlagSet("agent-t trust list", flag.ExitOnError)
+	fs.Parse(args)
+
+	entries := loadTrust().Entries()
+	if len(entries) == 0 {
+		fmt.Println("No trusted repository configs.")
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tTRUSTED\tFILE")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", trustStatus(e), e.Trusted.Local().Format("2006-01-02 15:04"), e.File)
+	}
+	tw.Flush()
+}
+
+// trustStatus compares an approval with the file as it is now.
+func trustStatus(e trust.Entry) string {
+	data, err := os.ReadFile(e.File)
+	switch {
+	case os.IsNotExist(err):
+		return "missing"
+	case err != nil:
+		return "unreadable"
+	case trust.Hash(data) != e.Hash:
+		return "changed"
+	default:
+		return "trusted"
+	}
+}
+
+func trustRevoke(args []string) {
+	fs := flag.NewFlagSet("agent-t trust revoke", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fatalf("Error: trust revoke needs the config file or project directory to revoke")
+	}
+
+	// Paths that cannot be revoked are reported after the others are saved
+	store := loadTrust()
+	failed := false
+	for _, arg := range fs.Args() {
+		file, err := filepath.Abs(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed = true
+			continue
+		}
+		if fi, err := os.Stat(file); err == nil && fi.IsDir() {
+			file = filepath.Join(file, config.ProjectFile)
+		}
+		if !store.Revoke(file) {
+			fmt.Fprintf(os.Stderr, "Error: %s is not trusted\n", file)
+			failed = true
+			continue
+		}
+		fmt.Printf("Revoked %s\n", file)
+	}
+	if err := saveTrust(store); err != nil {
+		fatalf("Error: %v", err)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"agent-t/internal/config"
+	"agent-t/internal/launcher"
+	"agent-t/internal/worktree"
+)
+
+const cleanupUsage = `Usage:
+  agent-t cleanup [--dry-run] [--force]
+
+Removes the git worktrees agent-t created that are no longer needed: those
+whose branch has no commits of its own or is merged into the branch it was
+created from, and those deleted by hand. Worktrees with uncommitted changes
+or unmerged commits are kept unless --force is given.
+
+Flags:
+`
+
+// checkoutWorktrees moves every cell that runs a tool into a git worktree
+// and branch of its own. Without create the worktrees are only planned, so
+// dry runs show where the cells would start.
+func checkoutWorktrees(cfg *config.Config, cells []launcher.CellSpec, create bool) ([]launcher.CellSpec, error) {
+	root, err := cfg.WorktreeRoot()
+	if err != nil {
+		return nil, err
+	}
+	path, err := worktreeRecordsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	stamp := worktree.Stamp(time.Now())
+	var created []worktree.Record
+	var errs []error
+	for i := range cells {
+		if cells[i].Command == "" {
+			continue
+		}
+		spec, err := worktree.PlanCell(cells[i].Dir, root, stamp, i)
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if create {
+			rec, err := worktree.Create(spec)
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+			created = append(created, rec)
+		}
+		cells[i].Dir = spec.Dir
+	}
+
+	// Record what was created even when a later cell failed, so cleanup
+	// can find it
+	if len(created) > 0 {
+		if err := worktree.AddRecords(path, created...); err != nil {
+			errs = append(errs, fmt.Errorf("recording worktrees: %w", err))
+		}
+	}
+	return cells, errors.Join(errs...)
+}
+
+func worktreeRecordsPath() (string, error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "worktrees.json"), nil
+}
+
+// runCleanup removes recorded worktrees that are merged, unused or gone.
+func runCleanup(args []string) {
+	fs := flag.NewFlagSet("agent-t cleanup", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "show what would be removed without removing it")
+	force := fs.Bool("force", false, "also remove worktrees with uncommitted changes or unmerged commits")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), cleanupUsage)
+		fs.PrintDefaults()
+	}
+	if rest := parseInterspersed(fs, args); len(rest) > 0 {
+		fatalf("Error: unexpected argument %q", rest[0])
+	}
+
+	path, err := worktreeRecordsPath()
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+	records, err := worktree.LoadRecords(path)
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+	if len(records) == 0 {
+		fmt.Println("No worktrees to clean up.")
+		return
+	}
+
+	var removed []worktree.Record
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tSTATUS\tBRANCH\tWORKTREE")
+	for _, r := range records {
+		status, err := worktree.Check(r)
+		action := "removed"
+		switch {
+		case err != nil:
+			action = "error: " + err.Error()
+		case !status.Removable() && !*force:
+			action = "kept"
+		case *dryRun:
+			action = "would remove"
+		default:
+			if err := worktree.Remove(r, *force); err != nil {
+				action = "error: " + err.Error()
+			} else {
+				removed = append(removed, r)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", action, status, r.Branch, r.Path)
+	}
+	tw.Flush()
+
+	if len(removed) == 0 {
+		return
+	}
+	// Only the removed ones are dropped, keeping worktrees that a launch
+	// recorded meanwhile
+	if err := worktree.RemoveRecords(path, removed...); err != nil {
+		fatalf("Error: %v", err)
+	}
+}